@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+type ErrFileNotFound struct {
+	File string
+}
+
+func (e ErrFileNotFound) Error() string {
+	return fmt.Sprintf("file not found: %s", e.File)
+}
+
+type ErrExtraOperand struct {
+	File string
+}
+
+func (e ErrExtraOperand) Error() string {
+	return fmt.Sprintf("extra operand '%s' not allowed with -c", e.File)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	flag "github.com/spf13/pflag"
+
+	"my_sort/sorting"
+)
+
+// config is the parsed command line: the sort options and what the command
+// does with its files.
+type config struct {
+	opts       sorting.Options
+	files      []string // input files; "-" is stdin
+	output     string   // -o FILE: replaces FILE once the sort succeeds ("" = stdout)
+	check      bool     // -c: check whether the input is sorted; do not sort
+	checkQuiet bool     // -C, --check=quiet: like -c, but do not report the first bad line
+}
+
+// parseFlags parses command-line flags using pflag (supports GNU-style combined short flags).
+func parseFlags() (config, error) {
+	keyDefs := flag.StringArrayP("key", "k", nil, "Sort via a key; KEYDEF is POS1[,POS2][OPTS] with POS = F[.C], may be repeated")
+	sep := flag.StringP("separator", "t", "", "Field separator; may be longer than one character (default is a run of blanks)")
+	fieldRegex := flag.String("field-regex", "", "Separate fields by matches of the regular expression RE instead of -t")
+	numeric := flag.BoolP("numeric", "n", false, "Compare according to numerical value")
+	general := flag.BoolP("general-numeric-sort", "g", false, "Compare according to general numerical value")
+	decimalPoint := flag.String("decimal-point", ".", "Decimal point character used by -n")
+	thousandsSep := flag.String("thousands-sep", "", "Thousands separator character skipped by -n")
+	reverse := flag.BoolP("reverse", "r", false, "Reverse the result of comparisons")
+	unique := flag.BoolP("unique", "u", false, "Output only the first of lines with equal keys")
+	count := flag.Bool("count", false, "Prefix lines by the number of lines with equal keys, like uniq -c")
+	repeated := flag.Bool("repeated", false, "Only print lines whose keys occur more than once, one for each key")
+	allRepeated := flag.String("all-repeated", "", "Print all lines whose keys occur more than once; groups are delimited by METHOD: none, prepend or separate")
+	flag.Lookup("all-repeated").NoOptDefVal = "none"
+	stable := flag.BoolP("stable", "s", false, "Stabilize sort by disabling last-resort comparison")
+	month := flag.BoolP("month", "M", false, "Compare months (JAN < FEB < ... < DEC)")
+	human := flag.BoolP("human", "h", false, "Compare human-readable numbers (e.g., 2K, 1G)")
+	version := flag.BoolP("version-sort", "V", false, "Natural sort of (version) numbers within text")
+	versionScheme := flag.String("version-scheme", "", "Version ordering for -V: 'semver' sorts pre-releases before releases")
+	random := flag.BoolP("random-sort", "R", false, "Shuffle, but group identical keys")
+	randomSource := flag.String("random-source", "", "Get the seed for -R from FILE")
+	seed := flag.Uint64("seed", 0, "Seed -R with N for reproducible output")
+	ignore := flag.BoolP("ignore-blanks", "b", false, "Ignore leading blanks")
+	foldCase := flag.BoolP("ignore-case", "f", false, "Fold lower case to upper case characters")
+	dictionary := flag.BoolP("dictionary-order", "d", false, "Consider only blanks and alphanumeric characters")
+	ignoreNonprint := flag.BoolP("ignore-nonprinting", "i", false, "Consider only printable characters")
+	zero := flag.BoolP("zero-terminated", "z", false, "Line delimiter is NUL, not newline")
+	stripCR := flag.Bool("strip-trailing-cr", false, "Strip a trailing carriage return from input lines")
+	debug := flag.Bool("debug", false, "Annotate the part of the line used to sort, and warn about questionable usage to stderr")
+	locale := flag.String("locale", "", "Collate text by the rules of LOCALE, e.g. ru-RU (default from LC_ALL, LC_COLLATE or LANG)")
+	check := flag.StringP("check", "c", "", "Check whether the input is sorted; do not sort (WHEN: diagnose-first, quiet, silent)")
+	flag.Lookup("check").NoOptDefVal = "diagnose-first"
+	checkQuiet := flag.BoolP("check-quiet", "C", false, "Like -c, but do not report the first bad line")
+	merge := flag.BoolP("merge", "m", false, "Merge already sorted files; do not sort")
+	head := flag.Int("head", 0, "Output only the first N lines of the sorted result, like sort | head -n N")
+	tail := flag.Int("tail", 0, "Output only the last N lines of the sorted result, like sort | tail -n N")
+	output := flag.StringP("output", "o", "", "Write result to FILE instead of standard output")
+	bufferSize := flag.StringP("buffer-size", "S", "1024", "Use SIZE for the main memory buffer: N megabytes, or N followed by b, K, M, G, T or % of physical memory (0 = unlimited)")
+	parallel := flag.Int("parallel", runtime.GOMAXPROCS(0), "Sort with N goroutines concurrently")
+	tempDir := flag.StringP("temporary-directory", "T", "", "Use DIR for temporary files")
+
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"} // use stdin if no files provided
+	}
+
+	quiet := *checkQuiet
+	switch *check {
+	case "", "diagnose-first":
+	case "quiet", "silent":
+		quiet = true
+	default:
+		return config{}, sorting.ErrInvalidArgument{Option: "--check", Value: *check}
+	}
+	if quiet && *check == "diagnose-first" {
+		return config{}, sorting.ErrIncompatibleOptions{Options: "cC"}
+	}
+
+	if *debug {
+		switch {
+		case quiet:
+			return config{}, sorting.ErrIncompatibleOptions{Options: "C --debug"}
+		case *check != "":
+			return config{}, sorting.ErrIncompatibleOptions{Options: "c --debug"}
+		case *output != "":
+			return config{}, sorting.ErrIncompatibleOptions{Options: "o --debug"}
+		}
+	}
+
+	if *check != "" || quiet {
+		switch {
+		case *head > 0:
+			return config{}, sorting.ErrIncompatibleOptions{Options: "c --head"}
+		case *tail > 0:
+			return config{}, sorting.ErrIncompatibleOptions{Options: "c --tail"}
+		}
+	}
+
+	var fieldPattern *regexp.Regexp
+	if *fieldRegex != "" {
+		var err error
+		if fieldPattern, err = sorting.ParseFieldRegex(*fieldRegex); err != nil {
+			return config{}, err
+		}
+	}
+
+	bufferMb, err := parseBufferSize(*bufferSize)
+	if err != nil {
+		return config{}, err
+	}
+
+	decimal, ok := singleRune(*decimalPoint)
+	if !ok {
+		return config{}, sorting.ErrInvalidArgument{Option: "--decimal-point", Value: *decimalPoint}
+	}
+	thousands, ok := singleRune(*thousandsSep)
+	if !ok && *thousandsSep != "" {
+		return config{}, sorting.ErrInvalidArgument{Option: "--thousands-sep", Value: *thousandsSep}
+	}
+
+	if *locale == "" && sorting.ValidLocale(localeFromEnv()) {
+		// like setlocale, ignore a locale in the environment we do not know
+		*locale = localeFromEnv()
+	}
+
+	// --seed takes precedence over --random-source
+	randomSeed := *seed
+	switch {
+	case flag.CommandLine.Changed("seed"):
+	case *randomSource != "":
+		var err error
+		if randomSeed, err = readRandomSource(*randomSource); err != nil {
+			return config{}, err
+		}
+	default:
+		randomSeed = newRandomSeed()
+	}
+
+	keys := make([]sorting.SortKey, 0, len(*keyDefs))
+	for _, def := range *keyDefs {
+		key, err := sorting.ParseKey(def)
+		if err != nil {
+			return config{}, err
+		}
+		keys = append(keys, key)
+	}
+
+	opts := sorting.Options{
+		Keys:           keys,
+		NumericSort:    *numeric,
+		GeneralNumeric: *general,
+		DecimalPoint:   decimal,
+		ThousandsSep:   thousands,
+		Reverse:        *reverse,
+		Unique:         *unique,
+		Count:          *count,
+		Repeated:       *repeated,
+		AllRepeated:    *allRepeated,
+		Stable:         *stable,
+		Month:          *month,
+		Human:          *human,
+		VersionSort:    *version,
+		VersionScheme:  *versionScheme,
+		Random:         *random,
+		Seed:           randomSeed,
+		IgnoreBlanks:   *ignore,
+		FoldCase:       *foldCase,
+		Dictionary:     *dictionary,
+		IgnoreNonprint: *ignoreNonprint,
+		ZeroTerminated: *zero,
+		StripCR:        *stripCR,
+		Debug:          *debug,
+		Locale:         *locale,
+		Merge:          *merge,
+		Head:           *head,
+		Tail:           *tail,
+		Separator:      *sep,
+		FieldRegex:     fieldPattern,
+		BufferMb:       bufferMb,
+		TempDir:        *tempDir,
+		Parallel:       *parallel,
+	}
+	if err := opts.Validate(); err != nil {
+		return config{}, err
+	}
+	return config{
+		opts:       opts,
+		files:      files,
+		output:     *output,
+		check:      *check != "" || quiet,
+		checkQuiet: quiet,
+	}, nil
+}
+
+// parseBufferSize parses the -S SIZE in GNU syntax into whole megabytes,
+// rounding up. A number without a suffix counts megabytes.
+func parseBufferSize(size string) (int, error) {
+	invalid := sorting.ErrInvalidArgument{Option: "--buffer-size", Value: size}
+	digits, unit := size, byte('M')
+	if n := len(size); n > 0 && (size[n-1] < '0' || size[n-1] > '9') {
+		digits, unit = size[:n-1], size[n-1]
+	}
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+
+	var bytes uint64
+	switch unit {
+	case 'b':
+		bytes = n
+	case 'K', 'k':
+		bytes, err = scaleSize(n, 1<<10)
+	case 'M', 'm':
+		bytes, err = scaleSize(n, 1<<20)
+	case 'G', 'g':
+		bytes, err = scaleSize(n, 1<<30)
+	case 'T', 't':
+		bytes, err = scaleSize(n, 1<<40)
+	case '%':
+		if n > 100 {
+			return 0, invalid
+		}
+		var total uint64
+		if total, err = physicalMemory(); err == nil {
+			bytes = total / 100 * n
+		}
+	default:
+		return 0, invalid
+	}
+	mb := (bytes + 1<<20 - 1) >> 20
+	if err != nil || mb > math.MaxInt32 {
+		return 0, invalid
+	}
+	return int(mb), nil
+}
+
+// scaleSize returns n*unit, failing when the product overflows.
+func scaleSize(n, unit uint64) (uint64, error) {
+	if n > math.MaxUint64/unit {
+		return 0, strconv.ErrRange
+	}
+	return n * unit, nil
+}
+
+// physicalMemory returns the size of the physical memory in bytes, for
+// -S N%.
+func physicalMemory() (uint64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if kb, ok := strings.CutPrefix(line, "MemTotal:"); ok {
+			n, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(kb, "kB")), 10, 64)
+			return n << 10, err
+		}
+	}
+	return 0, errors.New("MemTotal not found in /proc/meminfo")
+}
+
+// localeFromEnv returns the collation locale named by the environment, in
+// the order of precedence POSIX gives LC_ALL, LC_COLLATE and LANG.
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// singleRune returns the character s consists of. ok is false unless s is
+// exactly one character.
+func singleRune(s string) (r rune, ok bool) {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == utf8.RuneError && size == 1 {
+		return 0, false
+	}
+	return r, true
+}
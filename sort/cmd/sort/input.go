@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// openInputs opens the named input files. The returned function closes
+// them all.
+func openInputs(ctx context.Context, fileNames []string) ([]io.Reader, func(), error) {
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	inputs := make([]io.Reader, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		r, err := openInput(ctx, fileName)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		closers = append(closers, r)
+		inputs = append(inputs, r)
+	}
+	return inputs, closeAll, nil
+}
+
+// openInput opens the named input file; "-" stands for stdin.
+func openInput(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	if fileName == "-" {
+		return &stdinReader{ctx: ctx}, nil
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, ErrFileNotFound{File: fileName}
+	}
+	return f, nil
+}
+
+// stdinReader reads stdin through a pipe that a separate goroutine fills, so
+// that a read blocked on a terminal or pipe returns as soon as ctx is done.
+// The goroutine starts with the first Read.
+type stdinReader struct {
+	ctx   context.Context
+	start sync.Once
+	pipe  *io.PipeReader
+}
+
+func (r *stdinReader) Read(p []byte) (int, error) {
+	r.start.Do(func() {
+		pr, pw := io.Pipe()
+		go func() {
+			_, err := io.Copy(pw, os.Stdin)
+			pw.CloseWithError(err)
+		}()
+		context.AfterFunc(r.ctx, func() { pw.CloseWithError(r.ctx.Err()) })
+		r.pipe = pr
+	})
+	return r.pipe.Read(p)
+}
+
+func (r *stdinReader) Close() error {
+	if r.pipe == nil {
+		return nil
+	}
+	return r.pipe.Close()
+}
@@ -1,16 +1,137 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"my_sort/internal/sorting"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"my_sort/sorting"
 )
 
-func main() {
-	opts := sorting.ParseFlags()
+// Exit statuses, as in GNU sort.
+const (
+	exitOK       = 0
+	exitDisorder = 1 // -c/-C found a line out of order
+	exitFailure  = 2 // any other error
+)
+
+// cleanupGrace is how long an interrupted sort may take to remove its
+// temporary files. A read blocked on a terminal or pipe cannot be cancelled,
+// so sort exits after it anyway.
+const cleanupGrace = 2 * time.Second
+
+// interrupted is the cancellation cause for a signal; sort exits with
+// 128 + the signal number, like a process killed by it.
+type interrupted struct {
+	sig syscall.Signal
+}
+
+func (e interrupted) Error() string {
+	return "interrupted by " + e.sig.String()
+}
+
+func (e interrupted) exitStatus() int {
+	return 128 + int(e.sig)
+}
+
+// cancelOnSignal cancels the returned context on SIGINT or SIGTERM.
+func cancelOnSignal() context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		cause := interrupted{sig: (<-signals).(syscall.Signal)}
+		cancel(cause)
+		time.Sleep(cleanupGrace)
+		os.Exit(cause.exitStatus())
+	}()
+	return ctx
+}
+
+// run sorts, merges or checks the input files as cfg says. The -o file is
+// replaced only once the sort succeeds, so it may also be one of the inputs.
+func run(ctx context.Context, cfg config) error {
+	if cfg.check {
+		return checkFile(ctx, cfg.opts, cfg.files)
+	}
+
+	inputs, closeInputs, err := openInputs(ctx, cfg.files)
+	if err != nil {
+		return err
+	}
+	defer closeInputs()
+
+	if cfg.output == "" {
+		return sorting.Sort(ctx, inputs, os.Stdout, cfg.opts)
+	}
+
+	f, err := createAtomic(cfg.output)
+	if err != nil {
+		return err
+	}
+	if err := sorting.Sort(ctx, inputs, f, cfg.opts); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
+
+// checkFile checks that the single input file is sorted. The returned
+// ErrNotSorted names the file, line number and text of the first bad line.
+func checkFile(ctx context.Context, opts sorting.Options, files []string) error {
+	if len(files) > 1 {
+		return ErrExtraOperand{File: files[1]}
+	}
+
+	r, err := openInput(ctx, files[0])
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	err = sorting.Check(ctx, r, opts)
+	if notSorted, ok := err.(sorting.ErrNotSorted); ok {
+		notSorted.File = files[0]
+		return notSorted
+	}
+	return err
+}
 
-	err := sorting.SortLines(opts)
+func main() {
+	cfg, err := parseFlags()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, "sort:", err)
+		os.Exit(exitFailure)
+	}
+
+	if cfg.opts.Debug {
+		for _, warning := range sorting.DebugWarnings(cfg.opts) {
+			fmt.Fprintln(os.Stderr, "sort:", warning)
+		}
+	}
+
+	ctx := cancelOnSignal()
+	err = run(ctx, cfg)
+	var cause interrupted
+	if errors.As(context.Cause(ctx), &cause) {
+		os.Exit(cause.exitStatus())
+	}
+	if err == nil {
+		os.Exit(exitOK)
+	}
+
+	var notSorted sorting.ErrNotSorted
+	if errors.As(err, &notSorted) {
+		if !cfg.checkQuiet {
+			fmt.Fprintln(os.Stderr, "sort:", err)
+		}
+		os.Exit(exitDisorder)
 	}
 
+	fmt.Fprintln(os.Stderr, "sort:", err)
+	os.Exit(exitFailure)
 }
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"my_sort/sorting"
+)
+
+// testDir holds the fixtures shared with the sorting package.
+const testDir = "../../sorting/test"
+
+// sortFile sorts the named file into a string through sorting.Sort.
+func sortFile(t *testing.T, path string, opts sorting.Options) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var out bytes.Buffer
+	if err := sorting.Sort(t.Context(), []io.Reader{f}, &out, opts); err != nil {
+		t.Fatalf("Sort failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestRun_MergeStdin(t *testing.T) {
+	files := []string{
+		filepath.Join(testDir, "merge_1.txt"),
+		filepath.Join(testDir, "merge_2.txt"),
+		filepath.Join(testDir, "merge_3.txt"),
+	}
+	opts := sorting.Options{Merge: true}
+
+	inputs, closeInputs, err := openInputs(t.Context(), files)
+	if err != nil {
+		t.Fatalf("openInputs failed: %v", err)
+	}
+	defer closeInputs()
+	var want bytes.Buffer
+	if err := sorting.Sort(t.Context(), inputs, &want, opts); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	stdin, err := os.Open(files[1])
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", files[1], err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	inputs, closeInputs, err = openInputs(t.Context(), []string{files[0], "-", files[2]})
+	if err != nil {
+		t.Fatalf("openInputs with stdin failed: %v", err)
+	}
+	defer closeInputs()
+	var got bytes.Buffer
+	if err := sorting.Sort(t.Context(), inputs, &got, opts); err != nil {
+		t.Fatalf("merge with stdin failed: %v", err)
+	}
+
+	if got.String() != want.String() {
+		t.Errorf("merge with stdin differs:\ngot:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+}
+
+func TestRun_OutputInPlace(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testDir, "test.txt"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	want := sortFile(t, path, sorting.Options{})
+
+	if err := run(t.Context(), config{files: []string{path}, output: path}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("in-place output differs:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat output: %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("output mode = %v; want %v", info.Mode().Perm(), os.FileMode(0o640))
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary output was left behind: %d files in dir", len(entries))
+	}
+}
+
+func TestRun_OutputUntouchedOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		canceled bool
+		missing  bool
+	}{
+		{name: "missing_input", missing: true},
+		{name: "canceled", canceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "data.txt")
+			if err := os.WriteFile(path, []byte("b\na\n"), 0o644); err != nil {
+				t.Fatalf("failed to write input: %v", err)
+			}
+			cfg := config{files: []string{path}, output: path}
+			if tt.missing {
+				cfg.files = append(cfg.files, filepath.Join(dir, "missing.txt"))
+			}
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+
+			err := run(ctx, cfg)
+			if err == nil {
+				t.Fatal("run succeeded")
+			}
+			if tt.canceled && !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %v, want context.Canceled", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read output: %v", err)
+			}
+			if string(got) != "b\na\n" {
+				t.Errorf("output was modified: %q", got)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read dir: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("temporary output was left behind: %d files in dir", len(entries))
+			}
+		})
+	}
+}
+
+func TestCheckFile_MatchesUnixDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		opts     sorting.Options
+		unixArgs []string
+	}{
+		{"unsorted", "test.txt", sorting.Options{}, []string{"-c"}},
+		{"numeric", "test.txt", sorting.Options{NumericSort: true}, []string{"-c", "-n"}},
+		{"reverse", "test.txt", sorting.Options{Reverse: true}, []string{"-c", "-r"}},
+		{"sorted", "merge_1.txt", sorting.Options{}, []string{"-c"}},
+		{"sorted_key", "merge_n1.txt", sorting.Options{
+			Separator: "\t",
+			Keys:      []sorting.SortKey{{StartField: 2, EndField: 2, NumericSort: true, Reverse: true}},
+		}, []string{"-c", "-t", "\t", "-k2,2nr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(testDir, tt.file)
+			err := checkFile(t.Context(), tt.opts, []string{file})
+
+			cmd := exec.Command("sort", append(tt.unixArgs, file)...)
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			unixErr := cmd.Run()
+
+			if unixErr == nil {
+				if err != nil {
+					t.Fatalf("checkFile() = %v; unix sort reports the input as sorted", err)
+				}
+				return
+			}
+
+			var notSorted sorting.ErrNotSorted
+			if !errors.As(err, &notSorted) {
+				t.Fatalf("checkFile() = %v; want ErrNotSorted", err)
+			}
+			got := "sort: " + err.Error()
+			want := strings.TrimSuffix(stderr.String(), "\n")
+			if got != want {
+				t.Errorf("diagnostic = %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckFile_ExtraOperand(t *testing.T) {
+	files := []string{filepath.Join(testDir, "test.txt"), filepath.Join(testDir, "q")}
+	var extra ErrExtraOperand
+	if err := checkFile(t.Context(), sorting.Options{}, files); !errors.As(err, &extra) || extra.File != files[1] {
+		t.Errorf("checkFile() = %v; want ErrExtraOperand for %s", err, files[1])
+	}
+}
+
+func TestRun_OutputThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(target, []byte("b\na\n"), 0o644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink("data.txt", link); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if err := run(t.Context(), config{files: []string{link}, output: link}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if dest, err := os.Readlink(link); err != nil || dest != "data.txt" {
+		t.Errorf("link was replaced: Readlink = %q, %v", dest, err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if string(got) != "a\nb\n" {
+		t.Errorf("target = %q; want %q", got, "a\nb\n")
+	}
+}
+
+func TestParseBufferSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int
+		wantErr bool
+	}{
+		{size: "0", want: 0},
+		{size: "64", want: 64},
+		{size: "10M", want: 10},
+		{size: "2G", want: 2048},
+		{size: "1T", want: 1 << 20},
+		{size: "1536K", want: 2},
+		{size: "100b", want: 1},
+		{size: "0K", want: 0},
+		{size: "-5", wantErr: true},
+		{size: "-5M", wantErr: true},
+		{size: "", wantErr: true},
+		{size: "M", wantErr: true},
+		{size: "10X", wantErr: true},
+		{size: "1.5G", wantErr: true},
+		{size: "101%", wantErr: true},
+		{size: "99999999999T", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseBufferSize(tt.size)
+			if tt.wantErr {
+				var invalid sorting.ErrInvalidArgument
+				if !errors.As(err, &invalid) {
+					t.Errorf("parseBufferSize(%q) = %d, %v; want ErrInvalidArgument", tt.size, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseBufferSize(%q) = %d, %v; want %d", tt.size, got, err, tt.want)
+			}
+		})
+	}
+
+	if got, err := parseBufferSize("50%"); err != nil || got <= 0 {
+		t.Errorf("parseBufferSize(\"50%%\") = %d, %v; want a positive size", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// atomicFile collects output under a temporary name in the directory of its
+// destination. Commit renames it into place, so the destination may also be
+// one of the inputs and stays untouched when sorting fails. A destination
+// that is a symlink is resolved first, so that the output is written through
+// the link like GNU sort does instead of replacing it.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+var tempSeq atomic.Uint32
+
+func createAtomic(path string) (*atomicFile, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	seed := uint32(time.Now().UnixNano())
+	for range 100 {
+		suffix := strconv.FormatUint(uint64(seed+tempSeq.Add(1)), 36)
+		name := filepath.Join(dir, "."+base+".sort-"+suffix)
+
+		// 0666 lets the umask decide the mode of newly created outputs.
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: f, path: path}, nil
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: path, Err: fs.ErrExist}
+}
+
+// Commit flushes the file to disk and replaces the destination with it,
+// keeping the permissions of an existing destination.
+func (f *atomicFile) Commit() error {
+	if info, err := os.Stat(f.path); err == nil {
+		if err := f.Chmod(info.Mode().Perm()); err != nil {
+			f.Abort()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the temporary file.
+func (f *atomicFile) Abort() {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+
+	"my_sort/sorting"
+)
+
+// randomSourceBytes is how much of a --random-source file seeds -R.
+const randomSourceBytes = 8
+
+// newRandomSeed returns a fresh seed for -R when neither --seed nor
+// --random-source is given.
+func newRandomSeed() uint64 {
+	return rand.Uint64()
+}
+
+// readRandomSource reads the -R seed from the start of the named file.
+func readRandomSource(name string) (uint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, ErrFileNotFound{File: name}
+	}
+	defer f.Close()
+
+	var buf [randomSourceBytes]byte
+	if _, err := io.ReadFull(f, buf[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return 0, sorting.ErrInvalidArgument{Option: "--random-source", Value: name}
+		}
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
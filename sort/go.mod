@@ -2,4 +2,7 @@ module my_sort
 
 go 1.24.6
 
-require github.com/spf13/pflag v1.0.10
+require (
+	github.com/spf13/pflag v1.0.10
+	golang.org/x/text v0.30.0
+)
@@ -0,0 +1,112 @@
+package sorting
+
+import "slices"
+
+// Order compares values of type T by one or more string keys taken from
+// them, with the orderings of -k. An Order is built with By and ThenBy, and
+// every ordering method applies to the last key added:
+//
+//	byUsage := sorting.By(func(u Usage) string { return u.Size }).Human().Reverse().
+//		ThenBy(func(u Usage) string { return u.Path })
+//	slices.SortStableFunc(usages, byUsage.Compare)
+//
+// Without an ordering method a key compares byte by byte. There is no
+// last-resort comparison: values whose keys are all equal compare equal.
+// Orders are immutable, so a common prefix can be shared by several of them.
+type Order[T any] struct {
+	keys []orderKey[T]
+	comp *Comparator
+}
+
+type orderKey[T any] struct {
+	text func(T) string
+	key  SortKey
+}
+
+// By returns an Order that compares values by the key text returns.
+func By[T any](text func(T) string) Order[T] {
+	return Order[T]{comp: NewComparator(Options{})}.ThenBy(text)
+}
+
+// ThenBy returns an Order that breaks ties of o by the key text returns.
+func (o Order[T]) ThenBy(text func(T) string) Order[T] {
+	o.keys = append(slices.Clip(o.keys), orderKey[T]{text: text})
+	return o
+}
+
+// Numeric compares the last key by numerical value, like -n.
+func (o Order[T]) Numeric() Order[T] {
+	return o.with(func(k *SortKey) { k.NumericSort = true })
+}
+
+// General compares the last key by general numerical value, like -g.
+func (o Order[T]) General() Order[T] {
+	return o.with(func(k *SortKey) { k.GeneralNumeric = true })
+}
+
+// Human compares the last key as a human-readable number such as 2K, like -h.
+func (o Order[T]) Human() Order[T] {
+	return o.with(func(k *SortKey) { k.Human = true })
+}
+
+// Month compares the last key as a month name, like -M.
+func (o Order[T]) Month() Order[T] {
+	return o.with(func(k *SortKey) { k.Month = true })
+}
+
+// Version compares the last key as a version number, like -V.
+func (o Order[T]) Version() Order[T] {
+	return o.with(func(k *SortKey) { k.VersionSort = true })
+}
+
+// IgnoreBlanks ignores leading blanks of the last key, like -b.
+func (o Order[T]) IgnoreBlanks() Order[T] {
+	return o.with(func(k *SortKey) { k.SkipStartBlanks = true })
+}
+
+// FoldCase compares the last key with lower case folded to upper case, like -f.
+func (o Order[T]) FoldCase() Order[T] {
+	return o.with(func(k *SortKey) { k.FoldCase = true })
+}
+
+// Dictionary considers only blanks and alphanumeric characters of the last
+// key, like -d.
+func (o Order[T]) Dictionary() Order[T] {
+	return o.with(func(k *SortKey) { k.Dictionary = true })
+}
+
+// IgnoreNonprint considers only printable characters of the last key, like -i.
+func (o Order[T]) IgnoreNonprint() Order[T] {
+	return o.with(func(k *SortKey) { k.IgnoreNonprint = true })
+}
+
+// Reverse reverses the order of the last key, like -r.
+func (o Order[T]) Reverse() Order[T] {
+	return o.with(func(k *SortKey) { k.Reverse = true })
+}
+
+func (o Order[T]) with(set func(*SortKey)) Order[T] {
+	o.keys = slices.Clone(o.keys)
+	set(&o.keys[len(o.keys)-1].key)
+	return o
+}
+
+// Compare returns a negative number when a sorts before b, a positive one
+// when it sorts after b and zero when all keys are equal. Its method value
+// can be passed to slices.SortFunc and slices.SortStableFunc.
+func (o Order[T]) Compare(a, b T) int {
+	for _, k := range o.keys {
+		aText, bText := k.text(a), k.text(b)
+		if k.key.SkipStartBlanks {
+			aText, bText = aText[skipBlanks(aText, 0):], bText[skipBlanks(bText, 0):]
+		}
+		diff := compareKey(o.comp.parseKeyValue(aText, k.key), o.comp.parseKeyValue(bText, k.key), k.key)
+		if diff != 0 {
+			if k.key.Reverse {
+				return -diff
+			}
+			return diff
+		}
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package sorting
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// parseLocale turns a locale such as "ru-RU" or "ru_RU.UTF-8@euro" into a
+// language tag. ok is false for the C and POSIX locales, which sort by byte
+// value.
+func parseLocale(name string) (tag language.Tag, ok bool, err error) {
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || name == "C" || name == "POSIX" {
+		return language.Und, false, nil
+	}
+	tag, err = language.Parse(strings.ReplaceAll(name, "_", "-"))
+	return tag, err == nil, err
+}
+
+// ValidLocale reports whether Options.Locale may be set to name: a locale
+// such as "ru-RU" or "ru_RU.UTF-8", or the C and POSIX locales.
+func ValidLocale(name string) bool {
+	_, _, err := parseLocale(name)
+	return err == nil
+}
+
+// collator computes UCA sort keys for one goroutine; collate.Collator is not
+// safe for concurrent use.
+type collator struct {
+	*collate.Collator
+	buf collate.Buffer
+}
+
+// newCollators returns a pool of collators for the locale, or nil when the
+// locale sorts by byte value.
+func newCollators(locale string) *sync.Pool {
+	tag, ok, _ := parseLocale(locale)
+	if !ok {
+		return nil
+	}
+	return &sync.Pool{New: func() any {
+		return &collator{Collator: collate.New(tag)}
+	}}
+}
+
+// collationKey returns a string whose byte order is the order of s under the
+// comparator's locale.
+func (c *Comparator) collationKey(s string) string {
+	coll := c.collators.Get().(*collator)
+	key := string(coll.KeyFromString(&coll.buf, s))
+	coll.buf.Reset()
+	c.collators.Put(coll)
+	return key
+}
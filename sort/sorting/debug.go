@@ -0,0 +1,247 @@
+package sorting
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DebugWarnings returns the notes --debug prints before sorting, as GNU sort
+// does: how text is compared, keys that probably do not do what was meant
+// and global options that no key uses.
+func DebugWarnings(opts Options) []string {
+	var warnings []string
+	if _, ok, _ := parseLocale(opts.Locale); ok {
+		warnings = append(warnings, fmt.Sprintf("text ordering performed using '%s' sorting rules", opts.Locale))
+	} else {
+		warnings = append(warnings, "text ordering performed using simple byte comparison")
+	}
+
+	keys := opts.keyList()
+	globalOnly := len(opts.Keys) == 0
+	ignored := opts.globalKey() // global options no key makes use of
+
+	var numeric, general, numericSpan, generalSpan bool
+	for i, key := range keys {
+		n := i + 1
+		isNumeric := key.NumericSort || key.GeneralNumeric || key.Human
+		if isNumeric {
+			general = general || key.GeneralNumeric
+			numeric = numeric || !key.GeneralNumeric
+		}
+
+		zeroWidth := key.EndField != 0 && key.EndField < key.StartField
+		if zeroWidth {
+			warnings = append(warnings, fmt.Sprintf("key %d has zero width and will be ignored", n))
+		}
+
+		implicitSkip := isNumeric || key.Month
+		lineOffset := key.EndField == 1 && key.EndChar != 0
+		if !zeroWidth && !globalOnly && opts.fieldSep().blanks() && !lineOffset &&
+			(!key.SkipStartBlanks && (!implicitSkip || key.StartChar > 1) || !key.SkipEndBlanks && key.EndChar > 0) {
+			warnings = append(warnings, fmt.Sprintf("leading blanks are significant in key %d; consider also specifying 'b'", n))
+		}
+
+		if !globalOnly && isNumeric && (key.EndField == 0 || key.StartField < key.EndField) {
+			warnings = append(warnings, fmt.Sprintf("key %d is numeric and spans multiple fields", n))
+			generalSpan = generalSpan || key.GeneralNumeric
+			numericSpan = numericSpan || !key.GeneralNumeric
+		}
+
+		ignored.SkipStartBlanks = ignored.SkipStartBlanks && !key.SkipStartBlanks
+		ignored.SkipEndBlanks = ignored.SkipEndBlanks && !key.SkipEndBlanks
+		ignored.NumericSort = ignored.NumericSort && !key.NumericSort
+		ignored.GeneralNumeric = ignored.GeneralNumeric && !key.GeneralNumeric
+		ignored.Reverse = ignored.Reverse && !key.Reverse
+		ignored.Month = ignored.Month && !key.Month
+		ignored.Human = ignored.Human && !key.Human
+		ignored.VersionSort = ignored.VersionSort && !key.VersionSort
+		ignored.Random = ignored.Random && !key.Random
+		ignored.FoldCase = ignored.FoldCase && !key.FoldCase
+		ignored.Dictionary = ignored.Dictionary && !key.Dictionary
+		ignored.IgnoreNonprint = ignored.IgnoreNonprint && !key.IgnoreNonprint
+	}
+
+	decimalPoint, thousandsSep := opts.DecimalPoint, opts.ThousandsSep
+	if decimalPoint == 0 {
+		decimalPoint = '.'
+	}
+	// only a single-character -t can be mistaken for part of a number
+	sep, size := utf8.DecodeRuneInString(opts.Separator)
+	if size != len(opts.Separator) || sep == utf8.RuneError {
+		sep = 0
+	}
+	blanks := opts.fieldSep().blanks()
+	numberWarned := false
+	if numericSpan && thousandsSep != 0 &&
+		(blanks && (thousandsSep == ' ' || thousandsSep == '\t') || sep == thousandsSep) {
+		warnings = append(warnings, fmt.Sprintf("field separator '%c' is treated as a group separator in numbers", thousandsSep))
+		numberWarned = true
+	}
+	if numericSpan || generalSpan {
+		switch {
+		case blanks && thousandsSep != 0 && (decimalPoint == ' ' || decimalPoint == '\t') || sep == decimalPoint:
+			warnings = append(warnings, fmt.Sprintf("field separator '%c' is treated as a decimal point in numbers", decimalPoint))
+			numberWarned = true
+		case sep == '-':
+			warnings = append(warnings, "field separator '-' is treated as a minus sign in numbers")
+		case generalSpan && sep == '+':
+			warnings = append(warnings, "field separator '+' is treated as a plus sign in numbers")
+		}
+	}
+	if (numeric || general) && !numberWarned {
+		note := "note "
+		if sep == decimalPoint {
+			note = ""
+		}
+		warnings = append(warnings, fmt.Sprintf("%snumbers use '%c' as a decimal point in this locale", note, decimalPoint))
+	}
+
+	lastResortOnly := ignored.Reverse && !globalOnly
+	ignored.Reverse = ignored.Reverse && (opts.groupsLines() || opts.Stable)
+	if letters := ignored.optionLetters(); letters != "" {
+		if len(letters) == 1 {
+			warnings = append(warnings, fmt.Sprintf("option '-%s' is ignored", letters))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("options '-%s' are ignored", letters))
+		}
+	}
+	if lastResortOnly && !opts.groupsLines() && !opts.Stable {
+		warnings = append(warnings, "option '-r' only applies to last-resort comparison")
+	}
+	return warnings
+}
+
+// optionLetters spells the ordering options of the key the way GNU sort
+// lists them in diagnostics.
+func (k SortKey) optionLetters() string {
+	var b strings.Builder
+	for _, opt := range []struct {
+		set    bool
+		letter byte
+	}{
+		{k.SkipStartBlanks || k.SkipEndBlanks, 'b'},
+		{k.Dictionary, 'd'},
+		{k.FoldCase, 'f'},
+		{k.GeneralNumeric, 'g'},
+		{k.Human, 'h'},
+		{k.IgnoreNonprint, 'i'},
+		{k.Month, 'M'},
+		{k.NumericSort, 'n'},
+		{k.Random, 'R'},
+		{k.Reverse, 'r'},
+		{k.VersionSort, 'V'},
+	} {
+		if opt.set {
+			b.WriteByte(opt.letter)
+		}
+	}
+	return b.String()
+}
+
+// writeDebug writes line for --debug: tabs are shown as '>' and every key
+// is underlined on a line of its own, followed by the value it was parsed
+// to. A last underline marks the whole line, which breaks ties between
+// equal keys; tie says that it decided the order against the line before.
+// The underlines skip prefix, which is written in front of the line.
+func (lw *lineWriter) writeDebug(rec record, tie bool, prefix string) error {
+	w := lw.writer
+	line := rec.line
+	w.WriteString(prefix + strings.ReplaceAll(line, "\t", ">"))
+	w.WriteByte('\n')
+
+	indent := debugWidth(prefix)
+	for i, key := range lw.comp.keys {
+		beg, end, value := lw.comp.debugSpan(line, key, rec.keys[i])
+		markKey(w, indent+debugWidth(line[:beg]), debugWidth(line[beg:end]), value)
+	}
+	if len(lw.comp.keys) > 0 && (lw.opts.groupsLines() || lw.opts.Stable) {
+		return nil // there is no last-resort comparison
+	}
+	note := ""
+	if tie {
+		note = "last resort"
+	}
+	markKey(w, indent, debugWidth(line), note)
+	return nil
+}
+
+// debugSpan returns the part of line that key compares and the value parsed
+// from it. Numbers and months are narrowed down to the characters that were
+// read; an empty span means the key did not match.
+func (c *Comparator) debugSpan(line string, key SortKey, kv keyValue) (beg, end int, value string) {
+	sep := c.sep.forLine(line)
+	beg, end = keyStart(line, key, sep), keyEnd(line, key, sep)
+	if end < beg {
+		return beg, beg, ""
+	}
+	if !key.Month && !key.NumericSort && !key.GeneralNumeric && !key.Human {
+		return beg, end, ""
+	}
+
+	text := line[beg:end]
+	start := skipBlanks(text, 0)
+	n := start
+	switch {
+	case key.Month:
+		if kv.month != 0 {
+			n += 3
+			value = "M=" + strconv.Itoa(kv.month)
+		}
+	case key.GeneralNumeric:
+		if _, gend, ok := parseGeneralPrefix(text[start:]); ok {
+			n += gend
+			value = "g=" + kv.general.String()
+		}
+	default:
+		_, nend := parseDecimal(text, c.numbers)
+		if strings.IndexFunc(text[start:nend], unicode.IsDigit) >= 0 {
+			n = nend
+			value = "n=" + kv.number.String()
+			if key.Human {
+				value = "h=" + kv.number.String()
+				if n < len(text) && unitOrder[text[n]] != 0 {
+					value += string(text[n])
+					n++
+				}
+			}
+		}
+	}
+	return beg + start, beg + n, value
+}
+
+func (v generalValue) String() string {
+	if v.big != nil {
+		return v.big.Text('g', 10)
+	}
+	return strconv.FormatFloat(v.num, 'g', -1, 64)
+}
+
+// debugWidth returns the number of columns s takes in --debug output, where
+// a tab is shown as '>' and other control characters take no space.
+func debugWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r == '\t' || unicode.IsPrint(r) {
+			width++
+		}
+	}
+	return width
+}
+
+// markKey underlines width columns starting at offset and appends note.
+func markKey(w *bufio.Writer, offset, width int, note string) {
+	w.WriteString(strings.Repeat(" ", offset))
+	if width == 0 {
+		w.WriteString("^ no match for key\n")
+		return
+	}
+	w.WriteString(strings.Repeat("_", width))
+	if note != "" {
+		w.WriteString(" " + note)
+	}
+	w.WriteByte('\n')
+}
@@ -0,0 +1,49 @@
+package sorting
+
+import "fmt"
+
+// ErrNotSorted is returned by Check for the first line that is out of order.
+// File is left for the caller to fill in.
+type ErrNotSorted struct {
+	File string
+	Line int
+	Text string
+}
+
+func (e ErrNotSorted) Error() string {
+	return fmt.Sprintf("%s:%d: disorder: %s", e.File, e.Line, e.Text)
+}
+
+type ErrInvalidColumn struct {
+	Key int
+}
+
+func (e ErrInvalidColumn) Error() string {
+	return fmt.Sprintf("invalid column key: %d", e.Key)
+}
+
+type ErrInvalidKey struct {
+	Spec   string
+	Reason string
+}
+
+func (e ErrInvalidKey) Error() string {
+	return fmt.Sprintf("invalid key %q: %s", e.Spec, e.Reason)
+}
+
+type ErrInvalidArgument struct {
+	Option string
+	Value  string
+}
+
+func (e ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("invalid argument '%s' for '%s'", e.Value, e.Option)
+}
+
+type ErrIncompatibleOptions struct {
+	Options string
+}
+
+func (e ErrIncompatibleOptions) Error() string {
+	return fmt.Sprintf("options '-%s' are incompatible", e.Options)
+}
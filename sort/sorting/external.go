@@ -0,0 +1,139 @@
+package sorting
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// Memory a buffered line takes besides its bytes and keys: its string
+// header in the lines slice and its record, counted twice for the merge
+// buffer of sortChunks.
+const lineOverhead = int64(unsafe.Sizeof("")) + 2*int64(unsafe.Sizeof(record{}))
+
+// collationKeyRatio bounds the size of a collation key relative to its text;
+// the CLDR weights of a character take about five bytes.
+const collationKeyRatio = 5
+
+// lineCost returns the memory line takes once it is decorated for sorting.
+// It is what the -S limit is measured in.
+func (c *Comparator) lineCost(line string) int64 {
+	n := int64(len(line))
+	cost := n + lineOverhead + int64(len(c.keys))*int64(unsafe.Sizeof(keyValue{}))
+	for _, key := range c.keys {
+		switch {
+		case c.collators != nil && key.comparesText():
+			cost += collationKeyRatio * n
+		case key.FoldCase || key.Dictionary || key.IgnoreNonprint:
+			cost += n // translated copy of the key
+		}
+	}
+	if c.collators != nil {
+		cost += collationKeyRatio * n // rec.collated
+	}
+	return cost
+}
+
+// mergeFanIn is the largest number of runs merged at once, like NMERGE in
+// GNU sort. More runs are first merged in groups into bigger runs, so a
+// merge never holds more than mergeFanIn files open.
+const mergeFanIn = 16
+
+// writeRun stores already sorted lines in a new temporary file and returns
+// its name.
+func writeRun(lines []string, opts Options) (string, error) {
+	return createRun(opts, func(w *bufio.Writer) error {
+		for _, line := range lines {
+			if _, err := w.WriteString(line); err != nil {
+				return err
+			}
+			if err := w.WriteByte(opts.lineDelim()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createRun creates a temporary run file, fills it with write and returns
+// its name. The name is returned on failure too, so that it can be removed.
+func createRun(opts Options, write func(*bufio.Writer) error) (string, error) {
+	f, err := os.CreateTemp(opts.TempDir, "sort-run-*")
+	if err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriterSize(f, 1<<20)
+	if err := write(w); err != nil {
+		f.Close()
+		return f.Name(), err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return f.Name(), err
+	}
+	return f.Name(), f.Close()
+}
+
+func removeRuns(runs []string) {
+	for _, run := range runs {
+		_ = os.Remove(run)
+	}
+}
+
+// mergeRuns performs a k-way merge of the sorted run files into out. While
+// there are more than mergeFanIn runs, neighbouring runs are merged in
+// groups into new runs; keeping the groups in input order keeps the merge
+// stable.
+func mergeRuns(ctx context.Context, runs []string, comp *Comparator, opts Options, out io.Writer) error {
+	var created []string
+	defer func() { removeRuns(created) }()
+
+	// intermediate runs keep every line; -u, --count and --debug apply to
+	// the final merge only
+	pass := opts
+	pass.Unique, pass.Count, pass.Repeated, pass.AllRepeated, pass.Debug = false, false, false, "", false
+
+	for len(runs) > mergeFanIn {
+		var merged []string
+		for len(runs) > 0 {
+			group := runs[:min(mergeFanIn, len(runs))]
+			runs = runs[len(group):]
+			if len(group) == 1 {
+				merged = append(merged, group[0])
+				continue
+			}
+
+			run, err := createRun(opts, func(w *bufio.Writer) error {
+				return mergeRunFiles(ctx, group, comp, pass, w)
+			})
+			if run != "" {
+				created = append(created, run)
+			}
+			if err != nil {
+				return err
+			}
+			removeRuns(group)
+			merged = append(merged, run)
+		}
+		runs = merged
+	}
+	return mergeRunFiles(ctx, runs, comp, opts, out)
+}
+
+// mergeRunFiles merges at most mergeFanIn run files into out.
+func mergeRunFiles(ctx context.Context, runs []string, comp *Comparator, opts Options, out io.Writer) error {
+	inputs := make([]io.Reader, 0, len(runs))
+	for _, run := range runs {
+		f, err := os.Open(run)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	opts.StripCR = false // already done when the lines were read
+	return mergeSorted(ctx, inputs, comp, opts, out)
+}
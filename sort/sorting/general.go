@@ -0,0 +1,152 @@
+package sorting
+
+import (
+	"cmp"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// generalValue is a -g key parsed the way strtod does it. Values that do not
+// fit into a float64 (1e400, 1e-400) keep their exact value in big.
+type generalValue struct {
+	num float64
+	big *big.Float
+}
+
+// parseGeneral parses the longest prefix of s that strtod accepts: leading
+// white space, an optional sign and then a decimal or hexadecimal float,
+// "inf", "infinity" or "nan" with an optional "(chars)" suffix. ok is false
+// when no prefix of s is a number.
+func parseGeneral(s string) (v generalValue, ok bool) {
+	v, _, ok = parseGeneralPrefix(s)
+	return v, ok
+}
+
+// parseGeneralPrefix is parseGeneral that also returns the offset right
+// after the number, like the end pointer of strtod.
+func parseGeneralPrefix(s string) (v generalValue, end int, ok bool) {
+	i := 0
+	for i < len(s) && strings.IndexByte(" \t\n\v\f\r", s[i]) >= 0 {
+		i++
+	}
+	start := i
+	neg := false
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		neg = s[i] == '-'
+		i++
+	}
+
+	rest := s[i:]
+	switch {
+	case hasPrefixFold(rest, "inf"):
+		end = i + len("inf")
+		if hasPrefixFold(rest, "infinity") {
+			end = i + len("infinity")
+		}
+		if neg {
+			return generalValue{num: math.Inf(-1)}, end, true
+		}
+		return generalValue{num: math.Inf(1)}, end, true
+	case hasPrefixFold(rest, "nan"):
+		end = i + len("nan")
+		if j := strings.IndexByte(s[end:], ')'); strings.HasPrefix(s[end:], "(") && j > 0 &&
+			strings.IndexFunc(s[end+1:end+j], isNotNaNChar) < 0 {
+			end += j + 1
+		}
+		return generalValue{num: math.NaN()}, end, true
+	}
+
+	hex := len(rest) > 2 && rest[0] == '0' && (rest[1] == 'x' || rest[1] == 'X')
+	isDigit, expChar := isDecimalDigit, byte('e')
+	if hex {
+		isDigit, expChar = isHexDigit, 'p'
+		i += 2
+	}
+
+	digits, nonzero := 0, false
+	for pass := 0; pass < 2; pass++ {
+		for i < len(s) && isDigit(s[i]) {
+			nonzero = nonzero || s[i] != '0'
+			digits++
+			i++
+		}
+		if pass == 0 && i < len(s) && s[i] == '.' {
+			i++
+		} else {
+			break
+		}
+	}
+	if digits == 0 {
+		if hex {
+			// "0x" without hex digits is the number 0 followed by junk
+			return generalValue{}, i - 1, true
+		}
+		return generalValue{}, 0, false
+	}
+
+	text := s[start:i]
+	if i < len(s) && (s[i]|0x20) == expChar {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDecimalDigit(s[j]) {
+			for j < len(s) && isDecimalDigit(s[j]) {
+				j++
+			}
+			text = s[start:j]
+		}
+	}
+	end = start + len(text)
+	if hex && !strings.ContainsAny(text, "pP") {
+		text += "p0" // strconv needs the binary exponent of a hex float
+	}
+
+	num, err := strconv.ParseFloat(text, 64)
+	if err == nil && (num != 0 || !nonzero) {
+		return generalValue{num: num}, end, true
+	}
+
+	// Out of float64 range: keep the exact value.
+	f, _, err := big.ParseFloat(text, 0, 64, big.ToNearestEven)
+	if err != nil {
+		return generalValue{num: num}, end, true
+	}
+	return generalValue{num: num, big: f}, end, true
+}
+
+// isNotNaNChar reports whether r may not appear in the "(chars)" of a NaN.
+func isNotNaNChar(r rune) bool {
+	return !(r < utf8.RuneSelf && (isDecimalDigit(byte(r)) || isAlpha(byte(r)) || r == '_'))
+}
+
+// compareGeneral orders -g values: NaN first, then -inf, finite numbers and
+// +inf. NaNs compare equal to each other.
+func compareGeneral(a, b generalValue) int {
+	if (a.big == nil && b.big == nil) || math.IsNaN(a.num) || math.IsNaN(b.num) {
+		return cmp.Compare(a.num, b.num)
+	}
+	return a.bigFloat().Cmp(b.bigFloat())
+}
+
+func (v generalValue) bigFloat() *big.Float {
+	if v.big != nil {
+		return v.big
+	}
+	return new(big.Float).SetFloat64(v.num)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isDecimalDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDecimalDigit(c) || (c|0x20) >= 'a' && (c|0x20) <= 'f'
+}
@@ -0,0 +1,396 @@
+package sorting
+
+import (
+	"cmp"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortKey is one -k POS1[,POS2][OPTS] key definition, where POS is F[.C].
+// Field and character numbers are 1-based, as on the command line.
+type SortKey struct {
+	StartField int // POS1: field where the key starts
+	StartChar  int // POS1: character in the start field (0 = first)
+	EndField   int // POS2: field where the key ends (0 = end of line)
+	EndChar    int // POS2: last character in the end field (0 = end of field)
+
+	SkipStartBlanks bool // b on POS1: ignore leading blanks of the start field
+	SkipEndBlanks   bool // b on POS2: ignore leading blanks of the end field
+	NumericSort     bool // n: compare according to numerical value
+	GeneralNumeric  bool // g: compare according to general numerical value
+	Reverse         bool // r: reverse the result of comparisons
+	Month           bool // M: compare months
+	Human           bool // h: compare human-readable numbers
+	VersionSort     bool // V: natural sort of version numbers
+	Random          bool // R: shuffle by a hash of the key
+	FoldCase        bool // f: fold lower case to upper case characters
+	Dictionary      bool // d: consider only blanks and alphanumeric characters
+	IgnoreNonprint  bool // i: consider only printable characters
+}
+
+// ParseKey parses a KEYDEF such as "2", "3,3nr", "1b,1" or "2.3,2.5".
+func ParseKey(spec string) (SortKey, error) {
+	var key SortKey
+
+	field, s, ok := parseFieldNumber(spec)
+	if !ok {
+		return key, ErrInvalidKey{Spec: spec, Reason: "invalid number at field start"}
+	}
+	if field == 0 {
+		return key, ErrInvalidKey{Spec: spec, Reason: "field number is zero"}
+	}
+	key.StartField = field
+	if strings.HasPrefix(s, ".") {
+		key.StartChar, s, ok = parseFieldNumber(s[1:])
+		if !ok {
+			return key, ErrInvalidKey{Spec: spec, Reason: "invalid number after '.'"}
+		}
+		if key.StartChar == 0 {
+			return key, ErrInvalidKey{Spec: spec, Reason: "character offset is zero"}
+		}
+	}
+	s = key.setOrdering(s, true)
+
+	if strings.HasPrefix(s, ",") {
+		field, s, ok = parseFieldNumber(s[1:])
+		if !ok {
+			return key, ErrInvalidKey{Spec: spec, Reason: "invalid number after ','"}
+		}
+		if field == 0 {
+			return key, ErrInvalidKey{Spec: spec, Reason: "field number is zero"}
+		}
+		key.EndField = field
+		if strings.HasPrefix(s, ".") {
+			key.EndChar, s, ok = parseFieldNumber(s[1:])
+			if !ok {
+				return key, ErrInvalidKey{Spec: spec, Reason: "invalid number after '.'"}
+			}
+		}
+		s = key.setOrdering(s, false)
+	}
+
+	if s != "" {
+		return key, ErrInvalidKey{Spec: spec, Reason: "stray character in field spec"}
+	}
+	return key, nil
+}
+
+// parseFieldNumber splits the leading decimal number off s.
+func parseFieldNumber(s string) (int, string, bool) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, s, false
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, s, false
+	}
+	return n, s[i:], true
+}
+
+// setOrdering applies the option letters at the start of s to the key and
+// returns the rest of s. A 'b' refers to POS1 when start is set, else to POS2.
+func (k *SortKey) setOrdering(s string, start bool) string {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'b':
+			if start {
+				k.SkipStartBlanks = true
+			} else {
+				k.SkipEndBlanks = true
+			}
+		case 'n':
+			k.NumericSort = true
+		case 'g':
+			k.GeneralNumeric = true
+		case 'r':
+			k.Reverse = true
+		case 'M':
+			k.Month = true
+		case 'h':
+			k.Human = true
+		case 'V':
+			k.VersionSort = true
+		case 'R':
+			k.Random = true
+		case 'f':
+			k.FoldCase = true
+		case 'd':
+			k.Dictionary = true
+		case 'i':
+			k.IgnoreNonprint = true
+		default:
+			return s[i:]
+		}
+	}
+	return ""
+}
+
+// hasOrdering reports whether the key sets any option of its own other than
+// reverse. Keys without options inherit the global ones.
+func (k SortKey) hasOrdering() bool {
+	return k.SkipStartBlanks || k.SkipEndBlanks || k.NumericSort || k.GeneralNumeric || k.Month || k.Human ||
+		k.VersionSort || k.Random || k.FoldCase || k.Dictionary || k.IgnoreNonprint
+}
+
+// comparesText reports whether the key compares its text, collated under
+// --locale, rather than a value parsed from it.
+func (k SortKey) comparesText() bool {
+	return !k.NumericSort && !k.GeneralNumeric && !k.Month && !k.Human && !k.VersionSort && !k.Random
+}
+
+// keyList returns the keys to compare lines by. Keys without options of
+// their own inherit the global options. Without -k, global ordering options
+// form a single key spanning the whole line; with no options at all the
+// list is empty and lines are compared as a whole.
+func (opts Options) keyList() []SortKey {
+	global := opts.globalKey()
+	if len(opts.Keys) == 0 {
+		if !global.hasOrdering() {
+			return nil
+		}
+		return []SortKey{global}
+	}
+
+	keys := make([]SortKey, len(opts.Keys))
+	for i, key := range opts.Keys {
+		if !key.hasOrdering() && !key.Reverse {
+			global.StartField, global.StartChar = key.StartField, key.StartChar
+			global.EndField, global.EndChar = key.EndField, key.EndChar
+			key = global
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// globalKey returns the global ordering options as a key spanning the whole
+// line.
+func (opts Options) globalKey() SortKey {
+	return SortKey{
+		StartField:      1,
+		SkipStartBlanks: opts.IgnoreBlanks,
+		SkipEndBlanks:   opts.IgnoreBlanks,
+		NumericSort:     opts.NumericSort,
+		GeneralNumeric:  opts.GeneralNumeric,
+		Reverse:         opts.Reverse,
+		Month:           opts.Month,
+		Human:           opts.Human,
+		VersionSort:     opts.VersionSort,
+		Random:          opts.Random,
+		FoldCase:        opts.FoldCase,
+		Dictionary:      opts.Dictionary,
+		IgnoreNonprint:  opts.IgnoreNonprint,
+	}
+}
+
+// translate applies the f, d and i options to the text of a key. Letters
+// of every script are folded and kept by d, not only ASCII ones; bytes that
+// are not valid UTF-8 count as neither alphanumeric nor printable.
+func (k SortKey) translate(text string) string {
+	if !k.FoldCase && !k.Dictionary && !k.IgnoreNonprint {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		ch := text[i : i+size]
+		i += size
+
+		invalid := r == utf8.RuneError && size == 1
+		if k.Dictionary && (invalid || !(r == ' ' || r == '\t' || unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			continue
+		}
+		if k.IgnoreNonprint && (invalid || !unicode.IsPrint(r)) {
+			continue
+		}
+		if k.FoldCase && !invalid {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteString(ch)
+		}
+	}
+	return b.String()
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// keyStart returns the offset in line where the key begins. Without a
+// separator a field consists of its leading blanks plus the non-blank run.
+// sep must be prepared for line by forLine.
+func keyStart(line string, key SortKey, sep fieldSep) int {
+	ptr, lim := 0, len(line)
+
+	for skip := key.StartField - 1; ptr < lim && skip > 0; skip-- {
+		_, ptr = sep.skipField(line, ptr)
+	}
+
+	if key.SkipStartBlanks {
+		ptr = skipBlanks(line, ptr)
+	}
+	if key.StartChar > 1 {
+		ptr = skipChars(line, ptr, key.StartChar-1)
+	}
+	return ptr
+}
+
+// keyEnd returns the offset in line right after the key. The separator that
+// ends a field is only part of the key when the key reaches into the next
+// field. sep must be prepared for line by forLine.
+func keyEnd(line string, key SortKey, sep fieldSep) int {
+	ptr, lim := 0, len(line)
+	if key.EndField == 0 {
+		return lim
+	}
+
+	fields := key.EndField - 1
+	if key.EndChar == 0 {
+		fields++ // the whole end field belongs to the key
+	}
+	for ; ptr < lim && fields > 0; fields-- {
+		end, next := sep.skipField(line, ptr)
+		if fields > 1 || key.EndChar > 0 {
+			end = next
+		}
+		ptr = end
+	}
+
+	if key.EndChar > 0 {
+		if key.SkipEndBlanks {
+			ptr = skipBlanks(line, ptr)
+		}
+		ptr = skipChars(line, ptr, key.EndChar)
+	}
+	return ptr
+}
+
+func skipBlanks(line string, ptr int) int {
+	for ptr < len(line) && isBlank(line[ptr]) {
+		ptr++
+	}
+	return ptr
+}
+
+// skipChars advances ptr by n UTF-8 characters, stopping at the end of line.
+func skipChars(line string, ptr, n int) int {
+	for ; ptr < len(line) && n > 0; n-- {
+		_, size := utf8.DecodeRuneInString(line[ptr:])
+		ptr += size
+	}
+	return ptr
+}
+
+// fieldSep splits lines into fields at a literal string (-t), at matches of
+// a regular expression (--field-regex) or, when it has neither, before runs
+// of blanks.
+type fieldSep struct {
+	text    string
+	regex   *regexp.Regexp
+	matches [][]int // non-empty matches of regex in the current line; see forLine
+}
+
+// forLine returns s prepared for splitting line. The regex is matched once
+// against the whole line, so that ^ and \b see the text before a field and
+// skipping n fields does not rematch the line n times.
+func (s fieldSep) forLine(line string) fieldSep {
+	if s.regex == nil || s.matches != nil {
+		return s
+	}
+	all := s.regex.FindAllStringIndex(line, -1)
+	s.matches = make([][]int, 0, len(all))
+	for _, m := range all {
+		// empty matches do not separate fields
+		if m[1] > m[0] {
+			s.matches = append(s.matches, m)
+		}
+	}
+	return s
+}
+
+// ParseFieldRegex compiles the --field-regex expression expr. Expressions
+// that can match the empty string, such as "x*" or `\b`, are rejected: a
+// separator must not be empty.
+func ParseFieldRegex(expr string) (*regexp.Regexp, error) {
+	invalid := ErrInvalidArgument{Option: "--field-regex", Value: expr}
+	tree, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil || minMatchLen(tree.Simplify()) == 0 {
+		return nil, invalid
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, invalid
+	}
+	return re, nil
+}
+
+// minMatchLen returns the length in characters of the shortest text re
+// matches. Expressions that never match count as 1.
+func minMatchLen(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpNoMatch, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCharClass:
+		return 1
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCapture, syntax.OpPlus:
+		return minMatchLen(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * minMatchLen(re.Sub[0])
+	case syntax.OpConcat:
+		n := 0
+		for _, sub := range re.Sub {
+			n += minMatchLen(sub)
+		}
+		return n
+	case syntax.OpAlternate:
+		n := minMatchLen(re.Sub[0])
+		for _, sub := range re.Sub[1:] {
+			n = min(n, minMatchLen(sub))
+		}
+		return n
+	}
+	return 0 // empty-width assertions, x*, x?
+}
+
+// blanks reports whether fields are separated by runs of blanks.
+func (s fieldSep) blanks() bool {
+	return s.text == "" && s.regex == nil
+}
+
+// skipField returns the offset of the end of the field that starts at ptr
+// and the offset where the next field starts, after the separator. Both are
+// len(line) for the last field. With a regex, s must come from forLine.
+func (s fieldSep) skipField(line string, ptr int) (end, next int) {
+	switch {
+	case s.regex != nil:
+		i, _ := slices.BinarySearchFunc(s.matches, ptr, func(m []int, ptr int) int {
+			return cmp.Compare(m[0], ptr)
+		})
+		if i < len(s.matches) {
+			return s.matches[i][0], s.matches[i][1]
+		}
+		return len(line), len(line)
+	case s.text != "":
+		if i := strings.Index(line[ptr:], s.text); i >= 0 {
+			return ptr + i, ptr + i + len(s.text)
+		}
+		return len(line), len(line)
+	}
+
+	ptr = skipBlanks(line, ptr)
+	for ptr < len(line) && !isBlank(line[ptr]) {
+		ptr++
+	}
+	return ptr, ptr
+}
@@ -0,0 +1,88 @@
+package sorting
+
+import (
+	"container/heap"
+	"context"
+	"io"
+)
+
+// runReader is the head of one sorted input taking part in a merge.
+type runReader struct {
+	lines *lineReader
+	comp  *Comparator
+	rec   record
+	index int
+}
+
+func (r *runReader) next() (bool, error) {
+	line, ok, err := r.lines.next()
+	if ok {
+		r.rec = r.comp.decorate(line)
+	}
+	return ok, err
+}
+
+// runHeap orders run heads by their current line. Equal lines are taken from
+// the earlier input first, which keeps the merge stable.
+type runHeap struct {
+	readers []*runReader
+	comp    *Comparator
+}
+
+func (h *runHeap) Len() int { return len(h.readers) }
+
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.readers[i], h.readers[j]
+	if diff := h.comp.compareRecords(a.rec, b.rec); diff != 0 {
+		return diff < 0
+	}
+	return a.index < b.index
+}
+
+func (h *runHeap) Swap(i, j int) { h.readers[i], h.readers[j] = h.readers[j], h.readers[i] }
+
+func (h *runHeap) Push(x any) { h.readers = append(h.readers, x.(*runReader)) }
+
+func (h *runHeap) Pop() any {
+	last := h.readers[len(h.readers)-1]
+	h.readers = h.readers[:len(h.readers)-1]
+	return last
+}
+
+// mergeSorted performs a k-way merge of the sorted inputs into out.
+func mergeSorted(ctx context.Context, inputs []io.Reader, comp *Comparator, opts Options, out io.Writer) error {
+	h := &runHeap{comp: comp}
+	for i, input := range inputs {
+		r := &runReader{lines: newLineReader(input, opts), comp: comp, index: i}
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.readers = append(h.readers, r)
+		}
+	}
+	heap.Init(h)
+
+	lw := newLineWriter(out, comp, opts)
+	for h.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := h.readers[0]
+		if err := lw.WriteLine(r.rec.line); err != nil {
+			return err
+		}
+
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return lw.Flush()
+}
@@ -0,0 +1,136 @@
+package sorting
+
+import (
+	"cmp"
+	"strings"
+)
+
+// numberFormat holds the separators -n and -h use when reading numbers.
+type numberFormat struct {
+	decimal   string // decimal point, "." if empty
+	thousands string // thousands separator, none if empty
+}
+
+func newNumberFormat(opts Options) numberFormat {
+	nf := numberFormat{decimal: ".", thousands: ""}
+	if opts.DecimalPoint != 0 {
+		nf.decimal = string(opts.DecimalPoint)
+	}
+	if opts.ThousandsSep != 0 {
+		nf.thousands = string(opts.ThousandsSep)
+	}
+	return nf
+}
+
+// decimal is a number read by -n or -h. The digits are kept as text, so
+// numbers of any length compare exactly.
+type decimal struct {
+	neg  bool
+	int  string // integer digits without leading zeros
+	frac string // fraction digits without trailing zeros
+}
+
+func (d decimal) String() string {
+	s := d.int
+	if s == "" {
+		s = "0"
+	}
+	if d.frac != "" {
+		s += "." + d.frac
+	}
+	if d.neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// parseDecimal reads the number at the start of s the way GNU sort -n does:
+// leading blanks, an optional '-', digits that may be grouped by single
+// thousands separators between them and at most one decimal point. It
+// returns the number and the offset right after it. Whatever follows the
+// number is ignored, and a key without digits is zero.
+func parseDecimal(s string, nf numberFormat) (decimal, int) {
+	var d decimal
+	i := skipBlanks(s, 0)
+	if i < len(s) && s[i] == '-' {
+		d.neg = true
+		i++
+	}
+
+	start, grouped := i, false
+	for i < len(s) {
+		if isDecimalDigit(s[i]) {
+			i++
+		} else if nf.thousands != "" && i > start && isDecimalDigit(s[i-1]) &&
+			strings.HasPrefix(s[i:], nf.thousands) &&
+			i+len(nf.thousands) < len(s) && isDecimalDigit(s[i+len(nf.thousands)]) {
+			i += len(nf.thousands)
+			grouped = true
+		} else {
+			break
+		}
+	}
+	d.int = s[start:i]
+	if grouped {
+		d.int = strings.ReplaceAll(d.int, nf.thousands, "")
+	}
+	d.int = strings.TrimLeft(d.int, "0")
+
+	if strings.HasPrefix(s[i:], nf.decimal) {
+		i += len(nf.decimal)
+		start = i
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+		}
+		d.frac = strings.TrimRight(s[start:i], "0")
+	}
+
+	if d.int == "" && d.frac == "" {
+		d.neg = false // -0 is 0
+	}
+	return d, i
+}
+
+// compareDecimal compares two numbers digit by digit, without converting
+// them to a fixed-precision type.
+func compareDecimal(a, b decimal) int {
+	if a.neg != b.neg {
+		if a.neg {
+			return -1
+		}
+		return 1
+	}
+
+	diff := cmp.Compare(len(a.int), len(b.int))
+	if diff == 0 {
+		diff = strings.Compare(a.int, b.int)
+	}
+	if diff == 0 {
+		diff = strings.Compare(a.frac, b.frac)
+	}
+
+	if a.neg {
+		return -diff
+	}
+	return diff
+}
+
+var unitOrder = map[byte]int{
+	'k': 1, 'K': 1, 'M': 2, 'G': 3, 'T': 4, 'P': 5, 'E': 6, 'Z': 7, 'Y': 8,
+}
+
+// parseHuman reads a human-readable number such as "2K" or "-1.5G" for -h.
+// It returns the number and the order of its unit suffix. Numbers compare
+// by unit first and then by value, as in GNU sort; zero has no unit.
+func parseHuman(s string, nf numberFormat) (decimal, int) {
+	d, end := parseDecimal(s, nf)
+	if end >= len(s) || (d.int == "" && d.frac == "") {
+		return d, 0
+	}
+
+	unit := unitOrder[s[end]]
+	if d.neg {
+		return d, -unit
+	}
+	return d, unit
+}
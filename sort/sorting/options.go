@@ -0,0 +1,120 @@
+package sorting
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Options holds the options that control how lines are compared and written,
+// named after the command-line flags that set them.
+// The zero Options sorts whole lines by byte value.
+type Options struct {
+	Keys           []SortKey      // -k KEYDEF: sort keys, compared in order
+	Separator      string         // -t SEP: field separator, any number of characters ("" = fields are separated by runs of blanks)
+	FieldRegex     *regexp.Regexp // --field-regex RE: fields are separated by matches of RE instead
+	NumericSort    bool           // -n: compare according to numerical value
+	GeneralNumeric bool           // -g: compare according to general numerical value (floats, inf, nan)
+	DecimalPoint   rune           // --decimal-point C: decimal point for -n (0 = '.')
+	ThousandsSep   rune           // --thousands-sep C: thousands separator skipped by -n (0 = none)
+	Reverse        bool           // -r: reverse the result of comparisons
+	Unique         bool           // -u: output only the first of lines with equal keys
+	Count          bool           // --count: prefix each output line with the number of lines with equal keys it stands for
+	Repeated       bool           // --repeated: output only the first line of keys that occur more than once
+	AllRepeated    string         // --all-repeated[=METHOD]: output every line of keys that occur more than once; METHOD none, prepend or separate
+	Stable         bool           // -s: keep lines with equal keys in input order (no last-resort comparison)
+	Month          bool           // -M: compare months (JAN < FEB < ... < DEC)
+	Human          bool           // -h: compare human-readable numbers (e.g., 2K, 1G)
+	VersionSort    bool           // -V: natural sort of version numbers within text
+	VersionScheme  string         // --version-scheme: "" for GNU filevercmp, "semver" for SemVer 2.0 precedence
+	Random         bool           // -R: shuffle by a hash of the keys, keeping lines with equal keys together
+	Seed           uint64         // --seed N, --random-source FILE: seed of the -R hash; equal seeds give equal output
+	IgnoreBlanks   bool           // -b: ignore leading blanks
+	FoldCase       bool           // -f: fold lower case to upper case characters
+	Dictionary     bool           // -d: consider only blanks and alphanumeric characters
+	IgnoreNonprint bool           // -i: consider only printable characters
+	ZeroTerminated bool           // -z: lines end in NUL instead of newline
+	StripCR        bool           // --strip-trailing-cr: drop a '\r' at the end of input lines (CRLF input)
+	Debug          bool           // --debug: annotate the part of each line used for sorting
+	Locale         string         // --locale TAG: collate text with the CLDR rules of TAG, e.g. "ru-RU" ("" or "C" = byte order)
+	Merge          bool           // -m: merge already sorted inputs; do not sort
+	Head           int            // --head N: output only the first N lines of the sorted result, keeping N lines in memory (0 = all)
+	Tail           int            // --tail N: output only the last N lines of the sorted result, keeping N lines in memory (0 = all)
+	BufferMb       int            // -S N: main memory buffer in megabytes; bigger inputs are sorted via temp files (0 = unlimited)
+	TempDir        string         // -T DIR: directory for temporary files (default is os.TempDir())
+	Parallel       int            // --parallel N: number of goroutines sorting in memory (0 = GOMAXPROCS)
+}
+
+// Validate reports values Sort and Check do not accept and options that
+// cannot be combined, with the flag names of the command line.
+func (opts Options) Validate() error {
+	switch opts.AllRepeated {
+	case "", "none", "prepend", "separate":
+	default:
+		return ErrInvalidArgument{Option: "--all-repeated", Value: opts.AllRepeated}
+	}
+	if opts.Count && opts.AllRepeated != "" {
+		return ErrIncompatibleOptions{Options: "-count --all-repeated"}
+	}
+
+	if opts.Separator != "" && opts.FieldRegex != nil {
+		return ErrIncompatibleOptions{Options: "t --field-regex"}
+	}
+
+	if opts.Head < 0 {
+		return ErrInvalidArgument{Option: "--head", Value: strconv.Itoa(opts.Head)}
+	}
+	if opts.Tail < 0 {
+		return ErrInvalidArgument{Option: "--tail", Value: strconv.Itoa(opts.Tail)}
+	}
+	if opts.Head > 0 || opts.Tail > 0 {
+		top := "--head"
+		if opts.Head == 0 {
+			top = "--tail"
+		}
+		switch {
+		case opts.Head > 0 && opts.Tail > 0:
+			return ErrIncompatibleOptions{Options: "-head --tail"}
+		case opts.Merge:
+			return ErrIncompatibleOptions{Options: "m " + top}
+		case opts.groupsLines():
+			// the first N lines may hold fewer than N groups
+			return ErrIncompatibleOptions{Options: "u " + top}
+		}
+	}
+
+	switch opts.VersionScheme {
+	case "", "semver":
+	default:
+		return ErrInvalidArgument{Option: "--version-scheme", Value: opts.VersionScheme}
+	}
+
+	if !ValidLocale(opts.Locale) {
+		return ErrInvalidArgument{Option: "--locale", Value: opts.Locale}
+	}
+	return nil
+}
+
+// groupsLines reports whether lines with equal keys form groups on output,
+// of which -u, --count and --repeated write only the first line. Grouping
+// turns off the last-resort comparison, so that is the first input line.
+func (opts Options) groupsLines() bool {
+	return opts.Unique || opts.reportsGroups()
+}
+
+// reportsGroups reports whether the output depends on the size of groups.
+func (opts Options) reportsGroups() bool {
+	return opts.Count || opts.Repeated || opts.AllRepeated != ""
+}
+
+// fieldSep returns how lines are split into fields.
+func (opts Options) fieldSep() fieldSep {
+	return fieldSep{text: opts.Separator, regex: opts.FieldRegex}
+}
+
+// lineDelim returns the byte that ends input and output lines.
+func (opts Options) lineDelim() byte {
+	if opts.ZeroTerminated {
+		return 0
+	}
+	return '\n'
+}
@@ -0,0 +1,122 @@
+package sorting
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// minParallelChunk is the smallest number of lines worth sorting in a
+// separate goroutine.
+const minParallelChunk = 4096
+
+// maxSortChunk is the largest number of lines sorted without checking
+// whether the sort was cancelled.
+const maxSortChunk = 1 << 16
+
+// mergeCheckInterval is how many records a merge writes between checks of
+// its context.
+const mergeCheckInterval = 1 << 14
+
+// sortInMemory stably sorts lines in place. Every line is decorated with its
+// parsed keys once, then the records are sorted. The records are split into
+// chunks of at most maxSortChunk lines that are decorated and sorted by up
+// to workers goroutines and then merged pairwise; the result is identical
+// to a sequential stable sort. A done ctx stops the sort at the next chunk
+// boundary, leaving lines in an unspecified order.
+func sortInMemory(ctx context.Context, lines []string, comp *Comparator, workers int) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, len(lines)/minParallelChunk))
+
+	recs, err := sortChunks(ctx, lines, comp, workers)
+	if err != nil {
+		return err
+	}
+	for i := range recs {
+		lines[i] = recs[i].line
+	}
+	return nil
+}
+
+func sortChunks(ctx context.Context, lines []string, comp *Comparator, workers int) ([]record, error) {
+	chunks := max(workers, (len(lines)+maxSortChunk-1)/maxSortChunk)
+	bounds := make([]int, chunks+1)
+	for i := range bounds {
+		bounds[i] = i * len(lines) / chunks
+	}
+
+	// at most workers goroutines run at a time
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	spawn := func(f func()) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			f()
+		}()
+	}
+
+	recs := make([]record, len(lines))
+	for i := range chunks {
+		if ctx.Err() != nil {
+			break
+		}
+		lo, hi := bounds[i], bounds[i+1]
+		spawn(func() {
+			comp.decorateAll(recs[lo:hi], lines[lo:hi])
+			slices.SortStableFunc(recs[lo:hi], comp.compareRecords)
+		})
+	}
+	wg.Wait()
+
+	src, dst := recs, make([]record, len(recs))
+	for len(bounds) > 2 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		merged := []int{0}
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo := bounds[i]
+			if i+2 >= len(bounds) {
+				// odd chunk out, carried over to the next round
+				copy(dst[lo:bounds[i+1]], src[lo:bounds[i+1]])
+				merged = append(merged, bounds[i+1])
+				continue
+			}
+			mid, hi := bounds[i+1], bounds[i+2]
+			spawn(func() {
+				mergeChunks(ctx, dst[lo:hi], src[lo:mid], src[mid:hi], comp)
+			})
+			merged = append(merged, hi)
+		}
+		wg.Wait()
+		src, dst, bounds = dst, src, merged
+	}
+	return src, ctx.Err()
+}
+
+// mergeChunks merges the sorted slices left and right into dst. Equal
+// records are taken from left first to keep the merge stable. It gives up,
+// leaving dst incomplete, once ctx is done.
+func mergeChunks(ctx context.Context, dst, left, right []record, comp *Comparator) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if k%mergeCheckInterval == 0 && ctx.Err() != nil {
+			return
+		}
+		if comp.compareRecords(right[j], left[i]) < 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
@@ -0,0 +1,15 @@
+package sorting
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+)
+
+// randomHash is the -R sort value of a key. Equal keys hash alike, so lines
+// with the same key stay together however the seed shuffles them.
+func randomHash(seed uint64, text string) uint64 {
+	buf := make([]byte, 8, 8+len(text))
+	binary.LittleEndian.PutUint64(buf, seed)
+	sum := md5.Sum(append(buf, text...))
+	return binary.BigEndian.Uint64(sum[:8])
+}
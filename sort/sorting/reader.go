@@ -0,0 +1,61 @@
+package sorting
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// scanLines passes the lines of r to fn. It stops as soon as ctx is done.
+func scanLines(ctx context.Context, r io.Reader, opts Options, fn func(string) error) error {
+	lr := newLineReader(r, opts)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, ok, err := lr.next()
+		if !ok || err != nil {
+			return err
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// lineReader splits its input into lines ending in '\n', or NUL with -z.
+// Lines may have any length and keep every byte but the delimiter; a final
+// line without a delimiter is still a line.
+type lineReader struct {
+	reader  *bufio.Reader
+	delim   byte
+	stripCR bool
+}
+
+func newLineReader(r io.Reader, opts Options) *lineReader {
+	return &lineReader{
+		reader:  bufio.NewReaderSize(r, 1<<16),
+		delim:   opts.lineDelim(),
+		stripCR: opts.StripCR,
+	}
+}
+
+// next returns the next line. ok is false at the end of the input.
+func (lr *lineReader) next() (line string, ok bool, err error) {
+	line, err = lr.reader.ReadString(lr.delim)
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+	} else if err != nil {
+		return "", false, err
+	} else {
+		line = line[:len(line)-1]
+	}
+
+	if lr.stripCR {
+		line = strings.TrimSuffix(line, "\r")
+	}
+	return line, true, nil
+}
@@ -0,0 +1,74 @@
+package sorting
+
+// record is an input line decorated with its sort keys. Keys are extracted
+// and parsed once before sorting instead of in every comparison.
+type record struct {
+	line     string
+	keys     []keyValue
+	collated string // collation key of line under --locale
+}
+
+// keyValue is the text of one key plus the value parsed from it for the
+// key's ordering mode.
+type keyValue struct {
+	text    string       // key text after f, d and i; its collation key under --locale
+	number  decimal      // -n and -h value
+	unit    int          // -h unit order (K = 1, M = 2, ...), negated for negative numbers
+	month   int          // -M value, 0 if the key is not a month
+	general generalValue // -g value
+	version *semVersion  // -V value with --version-scheme=semver
+	hash    uint64       // -R hash of text
+	ok      bool         // general holds a parsed number
+}
+
+func (c *Comparator) decorate(line string) record {
+	rec := record{line: line}
+	if c.collators != nil {
+		rec.collated = c.collationKey(line)
+	}
+	if len(c.keys) == 0 {
+		return rec
+	}
+
+	rec.keys = make([]keyValue, len(c.keys))
+	sep := c.sep.forLine(line)
+	for i, key := range c.keys {
+		rec.keys[i] = c.parseKeyValue(getKeyColumn(line, key, sep), key)
+	}
+	return rec
+}
+
+// decorateAll decorates lines into recs, which must have the same length.
+func (c *Comparator) decorateAll(recs []record, lines []string) {
+	for i, line := range lines {
+		recs[i] = c.decorate(line)
+	}
+}
+
+func (c *Comparator) parseKeyValue(text string, key SortKey) keyValue {
+	kv := keyValue{text: text}
+	switch {
+	case key.Random:
+		kv.text = key.translate(text)
+		kv.hash = randomHash(c.seed, kv.text)
+	case key.Month:
+		kv.month = monthOrder(text)
+	case key.VersionSort:
+		kv.text = key.translate(text)
+		if c.semver {
+			kv.version = parseSemver(kv.text)
+		}
+	case key.Human:
+		kv.number, kv.unit = parseHuman(text, c.numbers)
+	case key.GeneralNumeric:
+		kv.general, kv.ok = parseGeneral(text)
+	case key.NumericSort:
+		kv.number, _ = parseDecimal(text, c.numbers)
+	default:
+		kv.text = key.translate(text)
+		if c.collators != nil {
+			kv.text = c.collationKey(kv.text)
+		}
+	}
+	return kv
+}
@@ -0,0 +1,385 @@
+package sorting
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Sort reads the lines of inputs, one input after the other, and writes
+// them sorted to out. With opts.Merge the inputs must already be sorted and
+// are merged instead. If opts.BufferMb is set, input that does not fit into
+// the buffer is sorted externally through temporary files. When ctx is done
+// Sort returns its error and removes the temporary files. The inputs are not
+// closed, and none is read when opts.Validate fails.
+func Sort(ctx context.Context, inputs []io.Reader, out io.Writer, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Merge {
+		return mergeSorted(ctx, inputs, NewComparator(opts), opts, out)
+	}
+	return sortReaders(ctx, inputs, opts, int64(opts.BufferMb)<<20, out)
+}
+
+// sortReaders sorts the inputs into out. Once the buffered lines take more
+// than limit bytes they are sorted and spilled to a temporary run file; the
+// runs are merged at the end. A limit <= 0 keeps everything in memory.
+// With opts.Head or opts.Tail only the lines for the output are kept.
+func sortReaders(ctx context.Context, inputs []io.Reader, opts Options, limit int64, out io.Writer) error {
+	if opts.Head > 0 || opts.Tail > 0 {
+		return sortTop(ctx, inputs, opts, out)
+	}
+
+	var (
+		lines []string
+		size  int64
+		runs  []string
+	)
+	defer func() { removeRuns(runs) }()
+
+	comp := NewComparator(opts)
+	spill := func() error {
+		if err := sortInMemory(ctx, lines, comp, opts.Parallel); err != nil {
+			return err
+		}
+		run, err := writeRun(lines, opts)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		lines, size = lines[:0], 0
+		return nil
+	}
+
+	for _, input := range inputs {
+		err := scanLines(ctx, input, opts, func(line string) error {
+			lines = append(lines, line)
+			size += comp.lineCost(line)
+			if limit > 0 && size >= limit {
+				return spill()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(runs) == 0 {
+		if len(lines) == 0 {
+			return nil
+		}
+		if err := sortInMemory(ctx, lines, comp, opts.Parallel); err != nil {
+			return err
+		}
+		return writeLines(ctx, out, lines, comp, opts)
+	}
+
+	if len(lines) > 0 {
+		if err := spill(); err != nil {
+			return err
+		}
+	}
+	return mergeRuns(ctx, runs, comp, opts, out)
+}
+
+// Comparator orders lines by the resolved key list of an Options. It is
+// safe for concurrent use.
+type Comparator struct {
+	keys      []SortKey
+	sep       fieldSep
+	reverse   bool
+	stable    bool // no last-resort comparison (-s, and -u keeps the first of equal lines)
+	numbers   numberFormat
+	semver    bool
+	seed      uint64
+	collators *sync.Pool // nil when text compares by byte value
+}
+
+// NewComparator returns the Comparator that Sort uses for opts.
+func NewComparator(opts Options) *Comparator {
+	return &Comparator{
+		keys:      opts.keyList(),
+		sep:       opts.fieldSep(),
+		reverse:   opts.Reverse,
+		stable:    opts.Stable || opts.groupsLines(),
+		numbers:   newNumberFormat(opts),
+		semver:    opts.VersionScheme == "semver",
+		seed:      opts.Seed,
+		collators: newCollators(opts.Locale),
+	}
+}
+
+// Compare returns a negative number when line a sorts before line b, a
+// positive one when it sorts after b and zero when the lines are equal, so
+// it can be passed to slices.SortFunc. The keys of both lines are parsed on
+// every call; Sort parses every line only once.
+func (c *Comparator) Compare(a, b string) int {
+	return c.compareRecords(c.decorate(a), c.decorate(b))
+}
+
+// compareRecords compares two decorated lines. Unless the comparator is
+// stable, lines with equal keys are ordered by the whole lines, collated
+// under --locale and then compared byte by byte. Lines that compare equal
+// keep their input order.
+func (c *Comparator) compareRecords(a, b record) int {
+	if len(c.keys) > 0 {
+		if diff := compareKeys(a.keys, b.keys, c.keys); diff != 0 || c.stable {
+			return diff
+		}
+	}
+
+	diff := strings.Compare(a.collated, b.collated)
+	if diff == 0 {
+		diff = strings.Compare(a.line, b.line)
+	}
+	if c.reverse {
+		return -diff
+	}
+	return diff
+}
+
+// getKeyColumn returns the part of line covered by key.
+func getKeyColumn(line string, key SortKey, sep fieldSep) string {
+	sep = sep.forLine(line)
+	start := keyStart(line, key, sep)
+	end := keyEnd(line, key, sep)
+	if end < start {
+		return ""
+	}
+	return line[start:end]
+}
+
+// compareKeys walks the keys in order and returns the first non-zero
+// comparison result, reversed for keys with the r option.
+func compareKeys(a, b []keyValue, keys []SortKey) int {
+	for i, key := range keys {
+		diff := compareKey(a[i], b[i], key)
+		if diff != 0 {
+			if key.Reverse {
+				return -diff
+			}
+			return diff
+		}
+	}
+	return 0
+}
+
+// compareKey compares two parsed keys according to the key's ordering
+// options.
+func compareKey(a, b keyValue, key SortKey) int {
+	switch {
+	case key.Random:
+		if a.hash != b.hash {
+			return cmp.Compare(a.hash, b.hash)
+		}
+		// keys whose hashes collide are told apart by their text
+	case key.Month:
+		return a.month - b.month
+	case key.GeneralNumeric:
+		if a.ok != b.ok {
+			// keys that are not numbers sort first
+			if a.ok {
+				return 1
+			}
+			return -1
+		}
+		return compareGeneral(a.general, b.general)
+	case key.NumericSort:
+		return compareDecimal(a.number, b.number)
+	case key.VersionSort:
+		if a.version != nil || b.version != nil {
+			return compareSemver(a.text, b.text, a.version, b.version)
+		}
+		return filevercmp(a.text, b.text)
+	case key.Human:
+		if a.unit != b.unit {
+			return cmp.Compare(a.unit, b.unit)
+		}
+		return compareDecimal(a.number, b.number)
+	}
+	return strings.Compare(a.text, b.text)
+}
+
+// Check reports whether the lines of in are sorted by opts. For the first
+// line that is out of order it returns an ErrNotSorted without a file name.
+func Check(ctx context.Context, in io.Reader, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	oc := newOrderChecker(opts)
+	return scanLines(ctx, in, opts, oc.add)
+}
+
+// orderChecker checks lines one at a time against the line before.
+type orderChecker struct {
+	comp   *Comparator
+	unique bool
+	prev   record
+	n      int
+}
+
+func newOrderChecker(opts Options) *orderChecker {
+	return &orderChecker{comp: NewComparator(opts), unique: opts.Unique}
+}
+
+func (oc *orderChecker) add(line string) error {
+	cur := oc.comp.decorate(line)
+	oc.n++
+	if oc.n > 1 {
+		// with -u, equal lines are out of order too
+		if diff := oc.comp.compareRecords(oc.prev, cur); diff > 0 || oc.unique && diff == 0 {
+			return ErrNotSorted{Line: oc.n, Text: line}
+		}
+	}
+	oc.prev = cur
+	return nil
+}
+
+func writeLines(ctx context.Context, out io.Writer, lines []string, comp *Comparator, opts Options) error {
+	lw := newLineWriter(out, comp, opts)
+	for _, line := range lines {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := lw.WriteLine(line); err != nil {
+			return err
+		}
+	}
+	return lw.Flush()
+}
+
+// lineWriter writes sorted lines one at a time, so that merged output can be
+// streamed without collecting it first. Lines that compare equal to the line
+// before, which means equal keys, or equal lines when there are no keys,
+// form a group: -u writes only its first line, --count prefixes that line
+// with the size of the group, and --repeated and --all-repeated leave out
+// groups of one line.
+type lineWriter struct {
+	writer  *bufio.Writer
+	opts    Options
+	comp    *Comparator
+	prev    record   // last line written, decorated when lines are grouped and for --debug
+	written bool     // prev holds a line
+	group   []record // lines of the current group not yet written
+	size    int      // number of lines in the current group
+	groups  int      // groups written, for --all-repeated separators
+}
+
+func newLineWriter(out io.Writer, comp *Comparator, opts Options) *lineWriter {
+	return &lineWriter{
+		writer: bufio.NewWriterSize(out, 4<<20),
+		opts:   opts,
+		comp:   comp,
+	}
+}
+
+func (lw *lineWriter) WriteLine(s string) error {
+	rec := record{line: s}
+	if lw.opts.groupsLines() || lw.opts.Debug {
+		rec = lw.comp.decorate(s)
+	}
+	repeat := lw.opts.groupsLines() && lw.written && lw.comp.compareRecords(lw.prev, rec) == 0
+
+	// with equal keys only the last-resort comparison ordered the lines
+	tie := lw.opts.Debug && lw.written && len(lw.comp.keys) > 0 &&
+		compareKeys(lw.prev.keys, rec.keys, lw.comp.keys) == 0
+	lw.prev, lw.written = rec, true
+
+	if !lw.opts.reportsGroups() {
+		if repeat {
+			return nil
+		}
+		return lw.writeRecord(rec, tie, "")
+	}
+
+	if !repeat {
+		if err := lw.flushGroup(); err != nil {
+			return err
+		}
+	}
+	if lw.size == 0 || lw.opts.AllRepeated != "" {
+		lw.group = append(lw.group, rec)
+	}
+	lw.size++
+	return nil
+}
+
+// flushGroup writes the current group for --count, --repeated and
+// --all-repeated.
+func (lw *lineWriter) flushGroup() error {
+	group, size := lw.group, lw.size
+	lw.group, lw.size = lw.group[:0], 0
+	if size == 0 || (size == 1 && (lw.opts.Repeated || lw.opts.AllRepeated != "")) {
+		return nil
+	}
+
+	if lw.opts.AllRepeated != "" {
+		if lw.opts.AllRepeated == "prepend" || (lw.opts.AllRepeated == "separate" && lw.groups > 0) {
+			if err := lw.writer.WriteByte(lw.opts.lineDelim()); err != nil {
+				return err
+			}
+		}
+		lw.groups++
+		for _, rec := range group {
+			if err := lw.writeRecord(rec, false, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	prefix := ""
+	if lw.opts.Count {
+		prefix = fmt.Sprintf("%7d ", size)
+	}
+	return lw.writeRecord(group[0], false, prefix)
+}
+
+// writeRecord writes one output line, preceded by prefix.
+func (lw *lineWriter) writeRecord(rec record, tie bool, prefix string) error {
+	if lw.opts.Debug {
+		return lw.writeDebug(rec, tie, prefix)
+	}
+	if _, err := lw.writer.WriteString(prefix); err != nil {
+		return err
+	}
+	if _, err := lw.writer.WriteString(rec.line); err != nil {
+		return err
+	}
+	return lw.writer.WriteByte(lw.opts.lineDelim())
+}
+
+func (lw *lineWriter) Flush() error {
+	if err := lw.flushGroup(); err != nil {
+		return err
+	}
+	return lw.writer.Flush()
+}
+
+var monthTable = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4,
+	"may": 5, "jun": 6, "jul": 7, "aug": 8,
+	"sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+func monthOrder(s string) int {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	if i+3 > len(s) {
+		return 0
+	}
+
+	token := strings.ToLower(s[i : i+3])
+	if num, ok := monthTable[token]; ok {
+		return num
+	}
+	return 0
+}
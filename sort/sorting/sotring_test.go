@@ -0,0 +1,1884 @@
+package sorting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestGetKeyColumn(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		key  SortKey
+		sep  string
+		want string
+	}{
+		{
+			name: "extract 2nd column using tab separator",
+			line: "first\tsecond\tthird",
+			key:  SortKey{StartField: 2, EndField: 2},
+			sep:  "\t",
+			want: "second",
+		},
+		{
+			name: "key without POS2 runs to end of line",
+			line: "first\tsecond\tthird",
+			key:  SortKey{StartField: 2},
+			sep:  "\t",
+			want: "second\tthird",
+		},
+		{
+			name: "field range spans several columns",
+			line: "a:b:c:d",
+			key:  SortKey{StartField: 2, EndField: 3},
+			sep:  ":",
+			want: "b:c",
+		},
+		{
+			name: "character positions inside one field",
+			line: "INV2024-00017 acme",
+			key:  SortKey{StartField: 1, StartChar: 4, EndField: 1, EndChar: 7},
+			want: "2024",
+		},
+		{
+			name: "character range spanning two fields",
+			line: "a:bcd:efg",
+			key:  SortKey{StartField: 2, StartChar: 2, EndField: 3, EndChar: 1},
+			sep:  ":",
+			want: "cd:e",
+		},
+		{
+			name: "end character past end of field stops at the line end",
+			line: "ab",
+			key:  SortKey{StartField: 1, StartChar: 2, EndField: 1, EndChar: 10},
+			want: "b",
+		},
+		{
+			name: "start after end yields empty key",
+			line: "abcdef",
+			key:  SortKey{StartField: 1, StartChar: 5, EndField: 1, EndChar: 2},
+			want: "",
+		},
+		{
+			name: "b on POS1 skips blanks before counting characters",
+			line: "x    hello",
+			key:  SortKey{StartField: 2, StartChar: 2, EndField: 2, SkipStartBlanks: true},
+			want: "ello",
+		},
+		{
+			name: "b on POS2 skips blanks before counting characters",
+			line: "x    hello",
+			key:  SortKey{StartField: 2, EndField: 2, EndChar: 3, SkipEndBlanks: true},
+			want: "    hel",
+		},
+		{
+			name: "character offsets count UTF-8 characters",
+			line: "ёжик ъ",
+			key:  SortKey{StartField: 1, StartChar: 2, EndField: 1, EndChar: 3},
+			want: "жи",
+		},
+		{
+			name: "key out of range with tab separator",
+			line: "onlyone",
+			key:  SortKey{StartField: 2, EndField: 2},
+			sep:  "\t",
+			want: "",
+		},
+		{
+			name: "key 1 without POS2 returns whole line",
+			line: "hello wombat",
+			key:  SortKey{StartField: 1},
+			sep:  " ",
+			want: "hello wombat",
+		},
+		{
+			name: "extract 3rd column with space separator",
+			line: "a b c d",
+			key:  SortKey{StartField: 3, EndField: 3},
+			sep:  " ",
+			want: "c",
+		},
+		{
+			name: "blank separated field keeps its leading blanks",
+			line: "a     b    c",
+			key:  SortKey{StartField: 2, EndField: 2},
+			want: "     b",
+		},
+		{
+			name: "b skips leading blanks of the field",
+			line: "a     b    c",
+			key:  SortKey{StartField: 2, EndField: 2, SkipStartBlanks: true},
+			want: "b",
+		},
+		{
+			name: "separator is 0 (default), fields split by space/tab",
+			line: "alpha\tbeta gamma",
+			key:  SortKey{StartField: 2, EndField: 2},
+			want: "\tbeta",
+		},
+		{
+			name: "separator explicitly set to space",
+			line: "x y z",
+			key:  SortKey{StartField: 2, EndField: 2},
+			sep:  " ",
+			want: "y",
+		},
+		{
+			name: "key exceeds number of fields with space separator",
+			line: "one two",
+			key:  SortKey{StartField: 4, EndField: 4},
+			sep:  " ",
+			want: "",
+		},
+		{
+			name: "empty line should return empty string",
+			line: "",
+			key:  SortKey{StartField: 1, EndField: 1},
+			sep:  "\t",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getKeyColumn(tt.line, tt.key, fieldSep{text: tt.sep})
+			if got != tt.want {
+				t.Errorf("getKeyColumn() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    SortKey
+		wantErr bool
+	}{
+		{"2", SortKey{StartField: 2}, false},
+		{"3,3nr", SortKey{StartField: 3, EndField: 3, NumericSort: true, Reverse: true}, false},
+		{"1b,1", SortKey{StartField: 1, EndField: 1, SkipStartBlanks: true}, false},
+		{"2,3b", SortKey{StartField: 2, EndField: 3, SkipEndBlanks: true}, false},
+		{"2M,2h", SortKey{StartField: 2, EndField: 2, Month: true, Human: true}, false},
+		{"1V", SortKey{StartField: 1, VersionSort: true}, false},
+		{"2,2R", SortKey{StartField: 2, EndField: 2, Random: true}, false},
+		{"1f,1di", SortKey{StartField: 1, EndField: 1, FoldCase: true, Dictionary: true, IgnoreNonprint: true}, false},
+		{"2.3,2.5", SortKey{StartField: 2, StartChar: 3, EndField: 2, EndChar: 5}, false},
+		{"1.9b,2.0n", SortKey{StartField: 1, StartChar: 9, EndField: 2, SkipStartBlanks: true, NumericSort: true}, false},
+		{"1.2,3.4b", SortKey{StartField: 1, StartChar: 2, EndField: 3, EndChar: 4, SkipEndBlanks: true}, false},
+		{"1.0", SortKey{}, true},
+		{"1.", SortKey{}, true},
+		{"1,2.", SortKey{}, true},
+		{"0", SortKey{}, true},
+		{"1,0", SortKey{}, true},
+		{"x", SortKey{}, true},
+		{"1,", SortKey{}, true},
+		{"2z", SortKey{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseKey(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseKey(%q) = %+v; want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		opts Options
+		want bool
+	}{
+
+		{"numeric ascending", "10", "2", Options{NumericSort: true}, false},
+		{"numeric descending", "10", "2", Options{NumericSort: true, Reverse: true}, true},
+
+		{"ignore blanks", "abc  ", "abc", Options{IgnoreBlanks: true}, false},
+
+		{"month order jan < feb", "Jan", "Feb", Options{Month: true}, true},
+		{"month order dec > nov", "Dec", "Nov", Options{Month: true}, false},
+
+		{"human readable K < M", "1K", "1M", Options{Human: true}, true},
+		{"human readable G > M", "2G", "1M", Options{Human: true}, false},
+
+		{
+			"first key decides", "b 1", "a 2",
+			Options{Keys: []SortKey{{StartField: 2, EndField: 2, NumericSort: true}, {StartField: 1, EndField: 1}}},
+			true,
+		},
+		{
+			"second key breaks tie", "x 5 b", "y 5 a",
+			Options{Keys: []SortKey{{StartField: 2, EndField: 2, NumericSort: true}, {StartField: 3, EndField: 3}}},
+			false,
+		},
+		{
+			"per-key reverse", "a 10", "b 9",
+			Options{Keys: []SortKey{{StartField: 2, EndField: 2, NumericSort: true, Reverse: true}}},
+			true,
+		},
+		{
+			"key without options inherits global ones", "a 10", "b 9",
+			Options{Keys: []SortKey{{StartField: 2, EndField: 2}}, NumericSort: true},
+			false,
+		},
+		{
+			"whole line decides when keys are equal", "b 1", "a 1",
+			Options{Keys: []SortKey{{StartField: 2, EndField: 2}}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewComparator(tt.opts).Compare(tt.a, tt.b) < 0
+			if got != tt.want {
+				t.Errorf("compare(%q, %q) < 0 = %v; want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestParseHuman(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		unit  int
+	}{
+		{"1K", "1", 1},
+		{"2M", "2", 2},
+		{"3.5G", "3.5", 3},
+		{"3.5Gв", "3.5", 3},
+		{"abc", "0", 0},
+		{"-1.5k", "-1.5", -1},
+		{"0K", "0", 0},
+		{"1024", "1024", 0},
+		{"1.K", "1", 1},
+		{"1.5.K", "1.5", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, unit := parseHuman(tt.input, numberFormat{decimal: "."})
+			if got.String() != tt.want || unit != tt.unit {
+				t.Errorf("parseHuman(%q) = (%v, %v); want (%v, %v)", tt.input, got, unit, tt.want, tt.unit)
+			}
+		})
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	dot := numberFormat{decimal: "."}
+	comma := numberFormat{decimal: ",", thousands: " "}
+	grouped := numberFormat{decimal: ".", thousands: ","}
+
+	tests := []struct {
+		input string
+		nf    numberFormat
+		want  string
+	}{
+		{"42", dot, "42"},
+		{"  -10", dot, "-10"},
+		{"-1.5", dot, "-1.5"},
+		{"1.2.3", dot, "1.2"},
+		{".5", dot, "0.5"},
+		{"+2", dot, "0"},
+		{"--3", dot, "0"},
+		{"abc", dot, "0"},
+		{"", dot, "0"},
+		{"-0.000", dot, "0"},
+		{"007.500", dot, "7.5"},
+		{"1,234", dot, "1"},
+		{"-1 234,50", comma, "-1234.5"},
+		{"1 000 000", comma, "1000000"},
+		{"3,14", comma, "3.14"},
+		{"3.14", comma, "3"},
+		{"1,234,567.5", grouped, "1234567.5"},
+		{",5", grouped, "0"},
+		{"-,5", grouped, "0"},
+		{"1,,2", grouped, "1"},
+		{"1,.5", grouped, "1"},
+		{"12,", grouped, "12"},
+		{"123456789012345678901234567890", dot, "123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got, _ := parseDecimal(tt.input, tt.nf); got.String() != tt.want {
+				t.Errorf("parseDecimal(%q) = %v; want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareDecimal(t *testing.T) {
+	order := []string{
+		"-100000000000000000000000000001",
+		"-100000000000000000000000000000",
+		"-2.5",
+		"-2.25",
+		"0",
+		"0.000000000000000000000000000001",
+		"1",
+		"1776544212345678901",
+		"1776544212345678902",
+		"100000000000000000000000000000",
+		"100000000000000000000000000000.5",
+	}
+	nf := numberFormat{decimal: "."}
+	for i := 1; i < len(order); i++ {
+		a, _ := parseDecimal(order[i-1], nf)
+		b, _ := parseDecimal(order[i], nf)
+		if compareDecimal(a, b) >= 0 || compareDecimal(b, a) <= 0 {
+			t.Errorf("%s should sort before %s", order[i-1], order[i])
+		}
+	}
+
+	a, _ := parseDecimal("-000.0", nf)
+	b, _ := parseDecimal("0", nf)
+	if compareDecimal(a, b) != 0 {
+		t.Errorf("-000.0 and 0 should compare equal")
+	}
+}
+
+func TestSortReaders_DecimalComma(t *testing.T) {
+	opts := Options{
+		Separator:    ";",
+		Keys:         []SortKey{{StartField: 2, EndField: 2, NumericSort: true}},
+		DecimalPoint: ',',
+		ThousandsSep: ' ',
+	}
+	got := sortText(t, opts, 0, "rent;-1 234,50\nsalary;2 000,00\nfee;-12,5\nnone;\nbonus;999,99\n")
+
+	want := "rent;-1 234,50\nfee;-12,5\nnone;\nbonus;999,99\nsalary;2 000,00\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSortReaders_RandomSeed(t *testing.T) {
+	var input strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&input, "key%02d\t%d\n", i%20, i)
+	}
+	shuffle := func(opts Options) []string {
+		t.Helper()
+		out := sortText(t, opts, 0, input.String())
+		return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	}
+	byKey := Options{Separator: "\t", Keys: []SortKey{{StartField: 1, EndField: 1, Random: true}}}
+
+	first := shuffle(Options{Random: true, Seed: 1})
+	if again := shuffle(Options{Random: true, Seed: 1}); !slices.Equal(first, again) {
+		t.Errorf("same seed gave different orders")
+	}
+	if other := shuffle(Options{Random: true, Seed: 2}); slices.Equal(first, other) {
+		t.Errorf("different seeds gave the same order")
+	}
+
+	byKey.Seed = 1
+	lines := shuffle(byKey)
+	seen := map[string]bool{}
+	for i, line := range lines {
+		key, _, _ := strings.Cut(line, "\t")
+		if i > 0 && strings.HasPrefix(lines[i-1], key+"\t") {
+			continue
+		}
+		if seen[key] {
+			t.Fatalf("lines with key %s are not adjacent", key)
+		}
+		seen[key] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("got %d key groups; want 20", len(seen))
+	}
+	if slices.IsSortedFunc(lines, strings.Compare) {
+		t.Errorf("-R left the lines in byte order")
+	}
+
+	dup := Options{Random: true, Unique: true, Seed: 1}
+	out := sortText(t, dup, 0, input.String(), input.String())
+	if got := strings.Count(out, "\n"); got != 200 {
+		t.Errorf("-R -u printed %d lines; want 200", got)
+	}
+}
+
+func TestSortReaders_FoldCaseUnicode(t *testing.T) {
+	input := "яблоко\nБанан\nЯблоко\nабрикос\nÉclair\néclair\n"
+	tests := []struct {
+		unique bool
+		want   string
+	}{
+		{false, "Éclair\néclair\nабрикос\nБанан\nЯблоко\nяблоко\n"},
+		{true, "Éclair\nабрикос\nБанан\nяблоко\n"},
+	}
+	for _, tt := range tests {
+		if got := sortText(t, Options{FoldCase: true, Unique: tt.unique}, 0, input); got != tt.want {
+			t.Errorf("unique=%v got:\n%s\nwant:\n%s", tt.unique, got, tt.want)
+		}
+	}
+}
+
+func TestSortReaders_Locale(t *testing.T) {
+	input := "яблоко\nЁж\nЯблоко\nель\nЕль\nарбуз\nжук\nеда\nёж\nzebra\nApple\napple\n"
+	tests := []struct {
+		locale string
+		keys   []SortKey
+		want   string
+	}{
+		{"ru-RU", nil, "apple\nApple\nzebra\nарбуз\nеда\nёж\nЁж\nель\nЕль\nжук\nяблоко\nЯблоко\n"},
+		{"ru_RU.UTF-8", []SortKey{{StartField: 1, Reverse: true}}, "Яблоко\nяблоко\nжук\nЕль\nель\nЁж\nёж\nеда\nарбуз\nzebra\nApple\napple\n"},
+		{"C", nil, "Apple\napple\nzebra\nЁж\nЕль\nЯблоко\nарбуз\nеда\nель\nжук\nяблоко\nёж\n"},
+	}
+	for _, tt := range tests {
+		if got := sortText(t, Options{Locale: tt.locale, Keys: tt.keys}, 0, input); got != tt.want {
+			t.Errorf("locale %s got:\n%s\nwant:\n%s", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		ok      bool
+		wantErr bool
+	}{
+		{"ru-RU", "ru-RU", true, false},
+		{"ru_RU.UTF-8", "ru-RU", true, false},
+		{"de_DE@euro", "de-DE", true, false},
+		{"C", "und", false, false},
+		{"C.UTF-8", "und", false, false},
+		{"POSIX", "und", false, false},
+		{"", "und", false, false},
+		{"not a locale", "und", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, ok, err := parseLocale(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLocale(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && (tag.String() != tt.want || ok != tt.ok) {
+				t.Errorf("parseLocale(%q) = (%s, %v); want (%s, %v)", tt.name, tag, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSortReaders_LineEndings(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{"crlf_stripped", "b\r\na\r\r\nc", Options{StripCR: true}, "a\r\nb\nc\n"},
+		{"zero_terminated_keeps_newlines", "b\nx\x00a\x00c y\r", Options{ZeroTerminated: true}, "a\x00b\nx\x00c y\r\x00"},
+		{"long_line", "b\n" + long + "\na\n", Options{}, "a\nb\n" + long + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.TempDir = t.TempDir()
+			for _, limit := range []int64{0, 1} {
+				if got := sortText(t, tt.opts, limit, tt.input); got != tt.want {
+					t.Errorf("limit %d: got %q; want %q", limit, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSortReaders_Debug(t *testing.T) {
+	opts := Options{
+		Separator: "\t",
+		Keys:      []SortKey{{StartField: 2, EndField: 2, Human: true}},
+		Debug:     true,
+	}
+	got := sortText(t, opts, 0, "b\t10K\na\t2M\nc\tx\ne\t2M\n")
+
+	want := `c>x
+  ^ no match for key
+___
+b>10K
+  ___ h=10K
+_____
+a>2M
+  __ h=2M
+____
+e>2M
+  __ h=2M
+____ last resort
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDebugWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "plain",
+			opts: Options{},
+			want: []string{"text ordering performed using simple byte comparison"},
+		},
+		{
+			name: "locale",
+			opts: Options{Locale: "ru-RU"},
+			want: []string{"text ordering performed using 'ru-RU' sorting rules"},
+		},
+		{
+			name: "numeric_key_spans_fields",
+			opts: Options{Keys: []SortKey{{StartField: 2, NumericSort: true}}, Month: true},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"key 1 is numeric and spans multiple fields",
+				"note numbers use '.' as a decimal point in this locale",
+				"option '-M' is ignored",
+			},
+		},
+		{
+			name: "blanks_and_reverse",
+			opts: Options{Keys: []SortKey{{StartField: 2, EndField: 2, Month: true}, {StartField: 1, StartChar: 2}}, Reverse: true, FoldCase: true},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"leading blanks are significant in key 2; consider also specifying 'b'",
+			},
+		},
+		{
+			name: "stable_reverse",
+			opts: Options{Keys: []SortKey{{StartField: 2, SkipStartBlanks: true}}, Reverse: true, FoldCase: true, Stable: true},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"options '-fr' are ignored",
+			},
+		},
+		{
+			name: "reverse_last_resort",
+			opts: Options{Keys: []SortKey{{StartField: 2, EndField: 2, Month: true}, {StartField: 1, StartChar: 2, EndField: 1, SkipStartBlanks: true}}, Reverse: true},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"option '-r' only applies to last-resort comparison",
+			},
+		},
+		{
+			name: "unique_reverse",
+			opts: Options{Keys: []SortKey{{StartField: 2, EndField: 1, Human: true}}, Reverse: true, Unique: true},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"key 1 has zero width and will be ignored",
+				"note numbers use '.' as a decimal point in this locale",
+				"option '-r' is ignored",
+			},
+		},
+		{
+			name: "separator_is_decimal_point",
+			opts: Options{Keys: []SortKey{{StartField: 1, EndField: 2, GeneralNumeric: true}}, Separator: "."},
+			want: []string{
+				"text ordering performed using simple byte comparison",
+				"key 1 is numeric and spans multiple fields",
+				"field separator '.' is treated as a decimal point in numbers",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DebugWarnings(tt.opts)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DebugWarnings() =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSortReaders_DuplicateReports(t *testing.T) {
+	input := "bob 3\nAlice 10\ncarol 3\nalice 2\ndave 03\nerin 7\nBob 1\n"
+	byName := []SortKey{{StartField: 1, EndField: 1}}
+	byNumber := []SortKey{{StartField: 2, EndField: 2, NumericSort: true}}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"count_fold_case", Options{Keys: byName, FoldCase: true, Count: true},
+			"      2 Alice 10\n      2 bob 3\n      1 carol 3\n      1 dave 03\n      1 erin 7\n"},
+		{"count_numeric_key", Options{Keys: byNumber, Count: true},
+			"      1 Bob 1\n      1 alice 2\n      3 bob 3\n      1 erin 7\n      1 Alice 10\n"},
+		{"repeated", Options{Keys: byName, FoldCase: true, Repeated: true},
+			"Alice 10\nbob 3\n"},
+		{"repeated_case_sensitive", Options{Keys: byName, Repeated: true},
+			""},
+		{"all_repeated_separate", Options{Keys: byName, FoldCase: true, AllRepeated: "separate"},
+			"Alice 10\nalice 2\n\nbob 3\nBob 1\n"},
+		{"all_repeated_prepend", Options{Keys: byNumber, AllRepeated: "prepend"},
+			"\nbob 3\ncarol 3\ndave 03\n"},
+		{"all_repeated_none", Options{NumericSort: true, AllRepeated: "none"},
+			input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortText(t, tt.opts, 0, input); got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeneral(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		ok    bool
+	}{
+		{"1e3", 1000, true},
+		{"-5", -5, true},
+		{"+2", 2, true},
+		{"0x1A", 26, true},
+		{"0x1p4", 16, true},
+		{"  -.5e1", -5, true},
+		{"1.5E-3", 0.0015, true},
+		{"1e", 1, true},
+		{"7x", 7, true},
+		{"inf", math.Inf(1), true},
+		{"-Infinity", math.Inf(-1), true},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"-", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseGeneral(tt.input)
+			if ok != tt.ok || got.num != tt.want {
+				t.Errorf("parseGeneral(%q) = (%v, %v); want (%v, %v)", tt.input, got.num, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCompareGeneral(t *testing.T) {
+	order := []string{"nan", "-inf", "-1e400", "-5", "0", "1e-400", "1", "1e400", "inf"}
+	for i := 1; i < len(order); i++ {
+		a, _ := parseGeneral(order[i-1])
+		b, _ := parseGeneral(order[i])
+		if compareGeneral(a, b) >= 0 || compareGeneral(b, a) <= 0 {
+			t.Errorf("%s should sort before %s", order[i-1], order[i])
+		}
+	}
+
+	nan, _ := parseGeneral("NaN")
+	if compareGeneral(nan, nan) != 0 {
+		t.Errorf("NaNs should compare equal")
+	}
+}
+
+func TestCompareVersions_Semver(t *testing.T) {
+	order := []string{
+		"app-1.9.0",
+		"app-2.0.0-alpha",
+		"app-2.0.0-alpha.1",
+		"app-2.0.0-alpha.beta",
+		"app-2.0.0-beta",
+		"app-2.0.0-rc.1",
+		"app-2.0.0-rc.2",
+		"app-2.0.0-rc.10",
+		"app-2.0.0",
+		"app-2.0.1",
+		"app-10.0.0",
+	}
+	comp := NewComparator(Options{VersionSort: true, VersionScheme: "semver"})
+	for i := 1; i < len(order); i++ {
+		if comp.Compare(order[i-1], order[i]) >= 0 || comp.Compare(order[i], order[i-1]) <= 0 {
+			t.Errorf("%s should sort before %s", order[i-1], order[i])
+		}
+	}
+
+	if got := comp.compareRecords(comp.decorate("x-1.0.0+build.5"), comp.decorate("x-1.0.0+build.1")); got <= 0 {
+		t.Errorf("build metadata should only matter in the last-resort comparison")
+	}
+	if got := compareKeys(comp.decorate("x-1.0.0+build.5").keys, comp.decorate("x-1.0.0+build.1").keys, comp.keys); got != 0 {
+		t.Errorf("compareKeys() = %d for versions differing in build metadata; want 0", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		opts    Options
+		wantErr bool
+	}{
+		{
+			name:    "empty input",
+			lines:   []string{},
+			opts:    Options{},
+			wantErr: false,
+		},
+		{
+			name:    "lexicographically sorted lines",
+			lines:   []string{"a", "b", "c"},
+			opts:    Options{},
+			wantErr: false,
+		},
+		{
+			name:    "lexicographically unsorted lines",
+			lines:   []string{"a", "c", "b"},
+			opts:    Options{},
+			wantErr: true,
+		},
+		{
+			name:    "numeric sort (-n) correct",
+			lines:   []string{"1", "2", "10"},
+			opts:    Options{NumericSort: true},
+			wantErr: false,
+		},
+		{
+			name:    "numeric sort (-n) incorrect",
+			lines:   []string{"1", "10", "2"},
+			opts:    Options{NumericSort: true},
+			wantErr: true,
+		},
+		{
+			name:    "reverse sort (-r) correct",
+			lines:   []string{"c", "b", "a"},
+			opts:    Options{Reverse: true},
+			wantErr: false,
+		},
+		{
+			name:    "reverse sort (-r) incorrect",
+			lines:   []string{"a", "b", "c"},
+			opts:    Options{Reverse: true},
+			wantErr: true,
+		},
+		{
+			name:    "human-readable sort (-h) correct",
+			lines:   []string{"1K", "1M", "1G"},
+			opts:    Options{Human: true},
+			wantErr: false,
+		},
+		{
+			name:    "human-readable sort (-h) incorrect",
+			lines:   []string{"1K", "1G", "1M"},
+			opts:    Options{Human: true},
+			wantErr: true,
+		},
+		{
+			name:    "month sort (-M) correct",
+			lines:   []string{"Jan", "Feb", "Mar"},
+			opts:    Options{Month: true},
+			wantErr: false,
+		},
+		{
+			name:    "month sort (-M) incorrect",
+			lines:   []string{"Jan", "Mar", "Feb"},
+			opts:    Options{Month: true},
+			wantErr: true,
+		},
+		{
+			name:    "unique (-u) equal keys",
+			lines:   []string{"1", "01", "2"},
+			opts:    Options{NumericSort: true, Unique: true},
+			wantErr: true,
+		},
+		{
+			name:    "unique (-u) strictly ascending",
+			lines:   []string{"1", "02", "3"},
+			opts:    Options{NumericSort: true, Unique: true},
+			wantErr: false,
+		},
+		{
+			name:    "russian collation correct",
+			lines:   []string{"ёлка", "ель", "Ель", "жук", "яблоко"},
+			opts:    Options{Locale: "ru-RU"},
+			wantErr: false,
+		},
+		{
+			name:    "russian collation in byte order",
+			lines:   []string{"Ель", "ель", "яблоко", "ёлка"},
+			opts:    Options{Locale: "ru-RU"},
+			wantErr: true,
+		},
+		{
+			name:    "C locale byte order",
+			lines:   []string{"Ель", "ель", "яблоко", "ёлка"},
+			opts:    Options{Locale: "C"},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(t.Context(), strings.NewReader(strings.Join(tt.lines, "\n")), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// sortText sorts inputs given as text and returns the output. A limit > 0
+// spills runs of about that many bytes to opts.TempDir.
+func sortText(t *testing.T, opts Options, limit int64, inputs ...string) string {
+	t.Helper()
+	readers := make([]io.Reader, len(inputs))
+	for i, input := range inputs {
+		readers[i] = strings.NewReader(input)
+	}
+	var out bytes.Buffer
+	if err := sortReaders(t.Context(), readers, opts, limit, &out); err != nil {
+		t.Fatalf("sort failed: %v", err)
+	}
+	return out.String()
+}
+
+// openFiles opens the named fixtures for reading; they are closed when the
+// test ends.
+func openFiles(t *testing.T, files ...string) []io.Reader {
+	t.Helper()
+	inputs := make([]io.Reader, len(files))
+	for i, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file, err)
+		}
+		t.Cleanup(func() { f.Close() })
+		inputs[i] = f
+	}
+	return inputs
+}
+
+func runSortTest(t *testing.T, opts Options, files []string, unixArgs []string) {
+	t.Helper()
+
+	testDir := "test"
+
+	tmpOut := filepath.Join(testDir, "tmp_out.txt")
+	tmpUnix := filepath.Join(testDir, "tmp_unix.txt")
+
+	out, err := os.Create(tmpOut)
+	if err != nil {
+		t.Fatalf("failed to create tmp output file: %v", err)
+	}
+	if err := Sort(t.Context(), openFiles(t, files...), out, opts); err != nil {
+		t.Fatalf("Sort failed: %v", err)
+	}
+	out.Close()
+
+	cmd := exec.Command("sort", append(unixArgs, files...)...)
+	unixOut, err := os.Create(tmpUnix)
+	if err != nil {
+		t.Fatalf("failed to create unix output file: %v", err)
+	}
+	cmd.Stdout = unixOut
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unix sort failed: %v", err)
+	}
+	unixOut.Close()
+
+	gotLines, err := os.ReadFile(tmpOut)
+	if err != nil {
+		t.Fatalf("failed to read tmp output file: %v", err)
+	}
+	wantLines, err := os.ReadFile(tmpUnix)
+	if err != nil {
+		t.Fatalf("failed to read unix output file: %v", err)
+	}
+
+	got := strings.Split(strings.TrimSpace(string(gotLines)), "\n")
+	want := strings.Split(strings.TrimSpace(string(wantLines)), "\n")
+
+	if len(got) != len(want) {
+		t.Fatalf("line count mismatch: got %d, want %d", len(got), len(want))
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// cleanup
+	if !t.Failed() {
+		_ = os.Remove(tmpOut)
+		_ = os.Remove(tmpUnix)
+	} else {
+		t.Logf("test failed, output files left in %s", testDir)
+	}
+}
+
+func TestSort_CompareWithUnixSort_Files(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		opts     Options
+		unixArgs []string
+	}{
+		{
+			name:     "simple",
+			files:    []string{"test/test.txt"},
+			opts:     Options{},
+			unixArgs: []string{},
+		}, {
+			name:     "reverse",
+			files:    []string{"test/test.txt"},
+			opts:     Options{Reverse: true},
+			unixArgs: []string{"-r"},
+		},
+		{
+			name:     "numeric",
+			files:    []string{"test/test.txt"},
+			opts:     Options{NumericSort: true},
+			unixArgs: []string{"-n"},
+		}, {
+			name:     "unique",
+			files:    []string{"test/test.txt"},
+			opts:     Options{Unique: true},
+			unixArgs: []string{"-u"},
+		}, {
+			name:     "month",
+			files:    []string{"test/test.txt"},
+			opts:     Options{Month: true},
+			unixArgs: []string{"-M"},
+		}, {
+			name:     "humanReadble",
+			files:    []string{"test/test.txt"},
+			opts:     Options{Human: true},
+			unixArgs: []string{"-h"},
+		}, {
+			name:     "ignoreBlanks",
+			files:    []string{"test/test.txt"},
+			opts:     Options{IgnoreBlanks: true},
+			unixArgs: []string{"-b"},
+		}, {
+			name:     "key",
+			files:    []string{"test/test.txt"},
+			opts:     Options{Keys: []SortKey{{StartField: 2}}},
+			unixArgs: []string{"-k2"},
+		}, {
+			name:  "reverse_unique",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Reverse: true,
+				Unique:  true,
+			},
+			unixArgs: []string{"-r", "-u"},
+		}, {
+			name:  "numeric_reverse",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				NumericSort: true,
+				Reverse:     true,
+			},
+			unixArgs: []string{"-n", "-r"},
+		},
+		{
+			name:  "numeric_unique",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				NumericSort: true,
+				Unique:      true,
+			},
+			unixArgs: []string{"-n", "-u"},
+		},
+		{
+			name:  "month_ignoreBlanks",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Month:        true,
+				IgnoreBlanks: true,
+			},
+			unixArgs: []string{"-M", "-b"},
+		},
+		{
+			name:  "human_reverse",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Human:   true,
+				Reverse: true,
+			},
+			unixArgs: []string{"-h", "-r"},
+		},
+		{
+			name:  "human_unique_ignoreBlanks",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Human:        true,
+				Unique:       true,
+				IgnoreBlanks: true,
+			},
+			unixArgs: []string{"-h", "-u", "-b"},
+		},
+		{
+			name:  "key_numeric",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys:        []SortKey{{StartField: 2}},
+				NumericSort: true,
+			},
+			unixArgs: []string{"-k2", "-n"},
+		},
+		{
+			name:  "key_human_reverse",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys:    []SortKey{{StartField: 2}},
+				Human:   true,
+				Reverse: true,
+			},
+			unixArgs: []string{"-k2", "-h", "-r"},
+		},
+		{
+			name:  "key_month_ignoreBlanks",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys:         []SortKey{{StartField: 2}},
+				Month:        true,
+				IgnoreBlanks: true,
+			},
+			unixArgs: []string{"-k2", "-M", "-b"},
+		},
+		{
+			name:  "everything_combined",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys:         []SortKey{{StartField: 2}},
+				Human:        true,
+				Reverse:      true,
+				Unique:       true,
+				IgnoreBlanks: true,
+			},
+			unixArgs: []string{"-k2", "-h", "-r", "-u", "-b"},
+		},
+		{
+			name:  "multiple_keys_numeric_reverse_then_alpha",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Separator: "\t",
+				Keys: []SortKey{
+					{StartField: 2, EndField: 2, NumericSort: true, Reverse: true},
+					{StartField: 1, EndField: 1},
+				},
+			},
+			unixArgs: []string{"-t", "\t", "-k2,2nr", "-k1,1"},
+		},
+		{
+			name:  "multiple_keys_alpha_then_reverse",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys: []SortKey{
+					{StartField: 2, EndField: 2},
+					{StartField: 1, EndField: 1, Reverse: true},
+				},
+			},
+			unixArgs: []string{"-k2,2", "-k1,1r"},
+		},
+		{
+			name:  "multiple_keys_tab_separator",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Separator: "\t",
+				Keys: []SortKey{
+					{StartField: 2, EndField: 2, Human: true},
+					{StartField: 1, EndField: 1, Reverse: true},
+				},
+			},
+			unixArgs: []string{"-t", "\t", "-k2,2h", "-k1,1r"},
+		},
+		{
+			name:  "multiple_keys_inherit_global_options",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				IgnoreBlanks: true,
+				Reverse:      true,
+				Keys: []SortKey{
+					{StartField: 1, EndField: 1},
+					{StartField: 2, EndField: 2, NumericSort: true},
+				},
+			},
+			unixArgs: []string{"-b", "-r", "-k1,1", "-k2,2n"},
+		},
+		{
+			name:  "character_positions",
+			files: []string{"test/invoices.txt"},
+			opts: Options{
+				Keys: []SortKey{
+					{StartField: 1, StartChar: 9, EndField: 1, EndChar: 13},
+					{StartField: 1, StartChar: 4, EndField: 1, EndChar: 7, Reverse: true},
+				},
+			},
+			unixArgs: []string{"-k1.9,1.13", "-k1.4,1.7r"},
+		},
+		{
+			name:  "character_position_numeric_to_end_of_line",
+			files: []string{"test/invoices.txt"},
+			opts: Options{
+				Keys: []SortKey{{StartField: 1, StartChar: 9, NumericSort: true}},
+			},
+			unixArgs: []string{"-k1.9n"},
+		},
+		{
+			name:  "character_range_across_fields",
+			files: []string{"test/invoices.txt"},
+			opts: Options{
+				Keys: []SortKey{{StartField: 1, StartChar: 9, EndField: 2, EndChar: 3}},
+			},
+			unixArgs: []string{"-k1.9,2.3"},
+		},
+		{
+			name:  "character_positions_with_blanks_skipped",
+			files: []string{"test/invoices.txt"},
+			opts: Options{
+				Separator: "\t",
+				Keys: []SortKey{
+					{StartField: 2, StartChar: 1, EndField: 2, EndChar: 2, SkipStartBlanks: true, SkipEndBlanks: true},
+					{StartField: 1, StartChar: 15, EndField: 1, EndChar: 16},
+				},
+			},
+			unixArgs: []string{"-t", "\t", "-k2.1b,2.2b", "-k1.15,1.16"},
+		},
+		{
+			name:  "merge",
+			files: []string{"test/merge_1.txt", "test/merge_2.txt", "test/merge_3.txt"},
+			opts: Options{
+				Merge: true,
+			},
+			unixArgs: []string{"-m"},
+		},
+		{
+			name:  "merge_unique",
+			files: []string{"test/merge_1.txt", "test/merge_2.txt", "test/merge_3.txt"},
+			opts: Options{
+				Merge:  true,
+				Unique: true,
+			},
+			unixArgs: []string{"-m", "-u"},
+		},
+		{
+			name:  "merge_key_numeric_reverse",
+			files: []string{"test/merge_n1.txt", "test/merge_n2.txt"},
+			opts: Options{
+				Merge:     true,
+				Separator: "\t",
+				Keys:      []SortKey{{StartField: 2, EndField: 2, NumericSort: true, Reverse: true}},
+			},
+			unixArgs: []string{"-m", "-t", "\t", "-k2,2nr"},
+		},
+		{
+			name:  "general_numeric",
+			files: []string{"test/floats.txt"},
+			opts: Options{
+				GeneralNumeric: true,
+			},
+			unixArgs: []string{"-g"},
+		},
+		{
+			name:  "general_numeric_reverse",
+			files: []string{"test/floats.txt"},
+			opts: Options{
+				GeneralNumeric: true,
+				Reverse:        true,
+			},
+			unixArgs: []string{"-g", "-r"},
+		},
+		{
+			name:  "key_general_numeric",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys: []SortKey{{StartField: 2, EndField: 2, GeneralNumeric: true}},
+			},
+			unixArgs: []string{"-k2,2g"},
+		},
+		{
+			name:  "numeric_signs_and_garbage",
+			files: []string{"test/numbers.txt"},
+			opts: Options{
+				NumericSort: true,
+			},
+			unixArgs: []string{"-n"},
+		},
+		{
+			name:  "numeric_signs_reverse",
+			files: []string{"test/numbers.txt"},
+			opts: Options{
+				NumericSort: true,
+				Reverse:     true,
+			},
+			unixArgs: []string{"-n", "-r"},
+		},
+		{
+			name:  "numeric_long_integers",
+			files: []string{"test/ids.txt"},
+			opts: Options{
+				Separator: "\t",
+				Keys:      []SortKey{{StartField: 2, EndField: 2, NumericSort: true}},
+			},
+			unixArgs: []string{"-t", "\t", "-k2,2n"},
+		},
+		{
+			name:  "human_units_and_signs",
+			files: []string{"test/sizes.txt"},
+			opts: Options{
+				Human: true,
+			},
+			unixArgs: []string{"-h"},
+		},
+		{
+			name:  "version_sort",
+			files: []string{"test/versions.txt"},
+			opts: Options{
+				VersionSort: true,
+			},
+			unixArgs: []string{"-V"},
+		},
+		{
+			name:  "version_sort_key_reverse",
+			files: []string{"test/versions.txt"},
+			opts: Options{
+				Keys: []SortKey{{StartField: 1, StartChar: 2, VersionSort: true, Reverse: true}},
+			},
+			unixArgs: []string{"-k1.2Vr"},
+		},
+		{
+			name:  "fold_case",
+			files: []string{"test/words.txt"},
+			opts: Options{
+				FoldCase: true,
+			},
+			unixArgs: []string{"-f"},
+		},
+		{
+			name:  "dictionary_order",
+			files: []string{"test/words.txt"},
+			opts: Options{
+				Dictionary: true,
+			},
+			unixArgs: []string{"-d"},
+		},
+		{
+			name:  "ignore_nonprinting",
+			files: []string{"test/words.txt"},
+			opts: Options{
+				IgnoreNonprint: true,
+			},
+			unixArgs: []string{"-i"},
+		},
+		{
+			name:  "dictionary_fold_case",
+			files: []string{"test/words.txt"},
+			opts: Options{
+				Dictionary: true,
+				FoldCase:   true,
+			},
+			unixArgs: []string{"-df"},
+		},
+		{
+			name:  "fold_case_per_key",
+			files: []string{"test/words.txt"},
+			opts: Options{
+				Separator: "\t",
+				Keys: []SortKey{
+					{StartField: 2, EndField: 2, Dictionary: true, FoldCase: true, Reverse: true},
+					{StartField: 1, EndField: 1},
+				},
+			},
+			unixArgs: []string{"-t", "\t", "-k2,2dfr", "-k1,1"},
+		},
+		{
+			name:  "stable_numeric_key",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys:   []SortKey{{StartField: 2, EndField: 2, NumericSort: true}},
+				Stable: true,
+			},
+			unixArgs: []string{"-s", "-k2,2n"},
+		},
+		{
+			name:  "stable_reverse_key",
+			files: []string{"test/invoices.txt"},
+			opts: Options{
+				Keys:    []SortKey{{StartField: 1, EndField: 1}},
+				Reverse: true,
+				Stable:  true,
+			},
+			unixArgs: []string{"-s", "-r", "-k1,1"},
+		},
+		{
+			name:  "stable_without_keys",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Stable: true,
+			},
+			unixArgs: []string{"-s"},
+		},
+		{
+			name:  "unique_numeric_first_in_input",
+			files: []string{"test/dups.txt"},
+			opts: Options{
+				NumericSort: true,
+				Unique:      true,
+			},
+			unixArgs: []string{"-un"},
+		},
+		{
+			name:  "unique_by_key",
+			files: []string{"test/dups.txt"},
+			opts: Options{
+				Keys:   []SortKey{{StartField: 2}},
+				Unique: true,
+			},
+			unixArgs: []string{"-u", "-k2"},
+		},
+		{
+			name:  "unique_by_numeric_key_reverse",
+			files: []string{"test/dups.txt"},
+			opts: Options{
+				Keys:    []SortKey{{StartField: 2, EndField: 2, NumericSort: true}},
+				Reverse: true,
+				Unique:  true,
+			},
+			unixArgs: []string{"-u", "-r", "-k2,2n"},
+		},
+		{
+			name:  "unique_fold_case_key",
+			files: []string{"test/dups.txt"},
+			opts: Options{
+				Keys:     []SortKey{{StartField: 2}},
+				FoldCase: true,
+				Unique:   true,
+			},
+			unixArgs: []string{"-uf", "-k2"},
+		},
+		{
+			name:  "unique_whole_lines_with_empty",
+			files: []string{"test/dups.txt"},
+			opts: Options{
+				Unique: true,
+			},
+			unixArgs: []string{"-u"},
+		},
+		{
+			name:     "crlf_kept",
+			files:    []string{"test/crlf.txt"},
+			opts:     Options{},
+			unixArgs: []string{},
+		},
+		{
+			name:     "zero_terminated",
+			files:    []string{"test/zero.txt"},
+			opts:     Options{ZeroTerminated: true},
+			unixArgs: []string{"-z"},
+		},
+		{
+			name:  "zero_terminated_key",
+			files: []string{"test/zero.txt"},
+			opts: Options{
+				ZeroTerminated: true,
+				Keys:           []SortKey{{StartField: 2}},
+			},
+			unixArgs: []string{"-z", "-k2"},
+		},
+		{
+			name:  "key_month_blanks_per_key",
+			files: []string{"test/test.txt"},
+			opts: Options{
+				Keys: []SortKey{{StartField: 1, EndField: 1, Month: true, SkipStartBlanks: true}},
+			},
+			unixArgs: []string{"-k1b,1M"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runSortTest(t, tc.opts, tc.files, tc.unixArgs)
+		})
+	}
+}
+
+func TestSortReaders_ExternalMatchesInMemory(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"simple", Options{}},
+		{"key", Options{Keys: []SortKey{{StartField: 2}}}},
+		{"numeric", Options{NumericSort: true}},
+		{"reverse_unique", Options{Reverse: true, Unique: true}},
+		{"month", Options{Month: true}},
+		{"human_reverse", Options{Human: true, Reverse: true}},
+		{"ignoreBlanks", Options{IgnoreBlanks: true}},
+		{"everything_combined", Options{Keys: []SortKey{{StartField: 2}}, Human: true, Reverse: true, Unique: true, IgnoreBlanks: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []string{"test/test.txt", "test/q"}
+			tt.opts.TempDir = t.TempDir()
+
+			var want bytes.Buffer
+			if err := sortReaders(t.Context(), openFiles(t, files...), tt.opts, 0, &want); err != nil {
+				t.Fatalf("in-memory sort failed: %v", err)
+			}
+
+			for _, limit := range []int64{1, 64, 256} {
+				var got bytes.Buffer
+				if err := sortReaders(t.Context(), openFiles(t, files...), tt.opts, limit, &got); err != nil {
+					t.Fatalf("external sort (limit %d) failed: %v", limit, err)
+				}
+				if got.String() != want.String() {
+					t.Errorf("external sort (limit %d) differs from in-memory sort:\ngot:\n%s\nwant:\n%s", limit, got.String(), want.String())
+				}
+			}
+
+			entries, err := os.ReadDir(tt.opts.TempDir)
+			if err != nil {
+				t.Fatalf("failed to read temp dir: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("temporary runs were not removed: %d files left", len(entries))
+			}
+		})
+	}
+}
+
+func TestSortInMemory_ParallelMatchesSequential(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := []string{"alpha", "Beta", "gamma", "delta", "Jan", "Feb", "Dec"}
+	lines := make([]string, maxSortChunk+5000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s %d %dK", words[rng.IntN(len(words))], rng.IntN(100), rng.IntN(50))
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"simple", Options{}},
+		{"reverse", Options{Reverse: true}},
+		{"key_numeric", Options{Keys: []SortKey{{StartField: 2, EndField: 2, NumericSort: true}}}},
+		{"key_month_then_human", Options{Keys: []SortKey{{StartField: 1, EndField: 1, Month: true}, {StartField: 3, Human: true, Reverse: true}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := NewComparator(tt.opts)
+
+			recs := make([]record, len(lines))
+			comp.decorateAll(recs, lines)
+			slices.SortStableFunc(recs, comp.compareRecords)
+			want := make([]string, len(recs))
+			for i, rec := range recs {
+				want[i] = rec.line
+			}
+
+			for _, workers := range []int{1, 2, 3, 8} {
+				got := slices.Clone(lines)
+				if err := sortInMemory(t.Context(), got, comp, workers); err != nil {
+					t.Fatalf("sortInMemory failed: %v", err)
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("chunked sort with %d workers differs from sequential sort", workers)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkLines(n int) []string {
+	rng := rand.New(rand.NewPCG(3, 4))
+	words := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	units := []string{"", "K", "M", "G"}
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s\t%d.%02d\t%d%s", words[rng.IntN(len(words))],
+			rng.IntN(1_000_000), rng.IntN(100), rng.IntN(1024), units[rng.IntN(len(units))])
+	}
+	return lines
+}
+
+// BenchmarkSortInMemory compares sorting records decorated once per line with
+// re-extracting and re-parsing the keys in every comparison.
+func BenchmarkSortInMemory(b *testing.B) {
+	lines := benchmarkLines(1_000_000)
+	comp := NewComparator(Options{
+		Separator: "\t",
+		Keys: []SortKey{
+			{StartField: 2, EndField: 2, NumericSort: true},
+			{StartField: 3, EndField: 3, Human: true, Reverse: true},
+		},
+	})
+
+	b.Run("decorated", func(b *testing.B) {
+		for b.Loop() {
+			sortInMemory(b.Context(), slices.Clone(lines), comp, 1)
+		}
+	})
+	b.Run("per_comparison", func(b *testing.B) {
+		for b.Loop() {
+			slices.SortStableFunc(slices.Clone(lines), comp.Compare)
+		}
+	})
+}
+
+func TestSort_Readers(t *testing.T) {
+	byNumber := []SortKey{{StartField: 2, EndField: 2, NumericSort: true}}
+	tests := []struct {
+		name   string
+		inputs []string
+		opts   Options
+		want   string
+	}{
+		{"zero_options", []string{"b\nA\n", "a\nB"}, Options{}, "A\nB\na\nb\n"},
+		{"numeric_key", []string{"x 10\ny 9\n", "z 100\n"}, Options{Keys: byNumber}, "y 9\nx 10\nz 100\n"},
+		{"merge", []string{"1\n3\n", "2\n4\n"}, Options{Merge: true}, "1\n2\n3\n4\n"},
+		{"unique_zero_terminated", []string{"b\x00a\x00", "a\x00"}, Options{Unique: true, ZeroTerminated: true}, "a\x00b\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputs []io.Reader
+			for _, input := range tt.inputs {
+				inputs = append(inputs, strings.NewReader(input))
+			}
+			var got bytes.Buffer
+			if err := Sort(context.Background(), inputs, &got, tt.opts); err != nil {
+				t.Fatalf("Sort failed: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Sort(ctx, []io.Reader{strings.NewReader("b\na\n")}, io.Discard, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Sort with a canceled context returned %v, want context.Canceled", err)
+	}
+}
+
+func TestSort_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"head_unique", Options{Head: 2, Unique: true}, ErrIncompatibleOptions{Options: "u --head"}},
+		{"tail_count", Options{Tail: 2, Count: true}, ErrIncompatibleOptions{Options: "u --tail"}},
+		{"head_merge", Options{Head: 2, Merge: true}, ErrIncompatibleOptions{Options: "m --head"}},
+		{"head_tail", Options{Head: 1, Tail: 1}, ErrIncompatibleOptions{Options: "-head --tail"}},
+		{"negative_head", Options{Head: -1}, ErrInvalidArgument{Option: "--head", Value: "-1"}},
+		{"count_all_repeated", Options{Count: true, AllRepeated: "none"}, ErrIncompatibleOptions{Options: "-count --all-repeated"}},
+		{"separator_and_regex", Options{Separator: ",", FieldRegex: regexp.MustCompile(",")}, ErrIncompatibleOptions{Options: "t --field-regex"}},
+		{"all_repeated_method", Options{AllRepeated: "both"}, ErrInvalidArgument{Option: "--all-repeated", Value: "both"}},
+		{"version_scheme", Options{VersionScheme: "pep440"}, ErrInvalidArgument{Option: "--version-scheme", Value: "pep440"}},
+		{"locale", Options{Locale: "xx-!!"}, ErrInvalidArgument{Option: "--locale", Value: "xx-!!"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Sort(t.Context(), []io.Reader{strings.NewReader("a\na\na\nb\nc\n")}, &out, tt.opts)
+			if err != tt.want {
+				t.Errorf("Sort() = %v; want %v", err, tt.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("Sort() wrote %q despite the error", out.String())
+			}
+		})
+	}
+}
+
+func TestComparator_SortFunc(t *testing.T) {
+	lines := []string{"b 10", "a 9", "c 10", "d 100"}
+	comp := NewComparator(Options{Keys: []SortKey{{StartField: 2, EndField: 2, NumericSort: true, Reverse: true}}})
+	slices.SortFunc(lines, comp.Compare)
+
+	want := []string{"d 100", "b 10", "c 10", "a 9"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestCheck_Reader(t *testing.T) {
+	err := Check(context.Background(), strings.NewReader("a\nc\nb\n"), Options{})
+	want := ErrNotSorted{Line: 3, Text: "b"}
+	if err != want {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	if err := Check(context.Background(), strings.NewReader("a\nb\nb\n"), Options{}); err != nil {
+		t.Errorf("sorted input: got %v", err)
+	}
+}
+
+func TestBy_SortFunc(t *testing.T) {
+	type usage struct {
+		path, size, month string
+	}
+	usages := []usage{
+		{"/var", "2K", "Feb"},
+		{"/home", "1G", "jan"},
+		{"/tmp", "2048", "JAN"},
+		{"/etc", "2K", "feb"},
+		{"/opt", "1G", "mar"},
+	}
+	bySize := By(func(u usage) string { return u.size }).Human().Reverse()
+
+	tests := []struct {
+		name  string
+		order Order[usage]
+		want  []string
+	}{
+		{"human_reverse", bySize, []string{"/home", "/opt", "/var", "/etc", "/tmp"}},
+		{"then_by_path", bySize.ThenBy(func(u usage) string { return u.path }),
+			[]string{"/home", "/opt", "/etc", "/var", "/tmp"}},
+		{"month_then_path", By(func(u usage) string { return u.month }).Month().
+			ThenBy(func(u usage) string { return u.path }).Reverse(),
+			[]string{"/tmp", "/home", "/var", "/etc", "/opt"}},
+		{"numeric", By(func(u usage) string { return u.size }).Numeric(),
+			[]string{"/home", "/opt", "/var", "/etc", "/tmp"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := slices.Clone(usages)
+			slices.SortStableFunc(sorted, tt.order.Compare)
+			var got []string
+			for _, u := range sorted {
+				got = append(got, u.path)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// cancelingReader cancels its context once it has been read up to the end.
+type cancelingReader struct {
+	io.Reader
+	cancel context.CancelFunc
+}
+
+func (r cancelingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestSort_Canceled(t *testing.T) {
+	t.Run("external_runs_removed", func(t *testing.T) {
+		runDir := t.TempDir()
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		input := cancelingReader{Reader: strings.NewReader(strings.Repeat("b\na\nc\n", 100)), cancel: cancel}
+
+		err := sortReaders(ctx, []io.Reader{input}, Options{TempDir: runDir}, 64, io.Discard)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+		entries, err := os.ReadDir(runDir)
+		if err != nil {
+			t.Fatalf("failed to read dir: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("run files were left behind: %d files in dir", len(entries))
+		}
+	})
+}
+
+func TestSortReaders_HeadTailMatchFullSort(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var input strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&input, "%d %d\n", rng.IntN(50), i)
+	}
+
+	byFirst := []SortKey{{StartField: 1, EndField: 1, NumericSort: true}}
+	variants := map[string]Options{
+		"whole_line":       {},
+		"numeric_reverse":  {NumericSort: true, Reverse: true},
+		"stable_key":       {Keys: byFirst, Stable: true},
+		"stable_key_rev":   {Keys: byFirst, Stable: true, Reverse: true},
+		"key_last_resort":  {Keys: byFirst},
+		"stable_fold_case": {Keys: []SortKey{{StartField: 1, EndField: 1, FoldCase: true}}, Stable: true},
+	}
+
+	for name, opts := range variants {
+		lines := strings.SplitAfter(sortText(t, opts, 0, input.String()), "\n")
+		lines = lines[:len(lines)-1]
+
+		for _, n := range []int{1, 7, 100, 6000} {
+			k := min(n, len(lines))
+			head, tail := opts, opts
+			head.Head, tail.Tail = n, n
+			if got := sortText(t, head, 0, input.String()); got != strings.Join(lines[:k], "") {
+				t.Errorf("%s --head %d: output differs from the full sort", name, n)
+			}
+			if got := sortText(t, tail, 0, input.String()); got != strings.Join(lines[len(lines)-k:], "") {
+				t.Errorf("%s --tail %d: output differs from the full sort", name, n)
+			}
+		}
+	}
+}
+
+func TestGetKeyColumn_FieldSeparators(t *testing.T) {
+	multi := fieldSep{text: " | "}
+	regex := fieldSep{regex: regexp.MustCompile(`\s*[,;]\s*`)}
+	tests := []struct {
+		name string
+		line string
+		key  SortKey
+		sep  fieldSep
+		want string
+	}{
+		{"multi_char_2nd_field", "a | b c | d", SortKey{StartField: 2, EndField: 2}, multi, "b c"},
+		{"multi_char_to_end", "a | b c | d", SortKey{StartField: 2}, multi, "b c | d"},
+		{"multi_char_partial_separator", "a|b | c", SortKey{StartField: 2, EndField: 2}, multi, "c"},
+		{"multi_char_chars", "a | bcd | e", SortKey{StartField: 2, StartChar: 2, EndField: 3, EndChar: 1}, multi, "cd | e"},
+		{"multi_char_missing_field", "a | b", SortKey{StartField: 3, EndField: 3}, multi, ""},
+		{"double_colon", "x::10::y", SortKey{StartField: 2, EndField: 2}, fieldSep{text: "::"}, "10"},
+		{"regex_2nd_field", "a , b;c", SortKey{StartField: 2, EndField: 2}, regex, "b"},
+		{"regex_3rd_field", "a , b;c", SortKey{StartField: 3, EndField: 3}, regex, "c"},
+		{"regex_span", "a , b;c", SortKey{StartField: 1, EndField: 2}, regex, "a , b"},
+		{"regex_anchored_once", "xaxb", SortKey{StartField: 2, EndField: 2}, fieldSep{regex: regexp.MustCompile(`^x`)}, "axb"},
+		{"regex_word_start", "a-b c-d", SortKey{StartField: 2, EndField: 2}, fieldSep{regex: regexp.MustCompile(`\b-`)}, "b c"},
+		{"regex_empty_matches_skipped", "ab,cd", SortKey{StartField: 2, EndField: 2}, fieldSep{regex: regexp.MustCompile(`,|\b`)}, "cd"},
+		{"regex_only_empty_matches", "ab cd", SortKey{StartField: 2, EndField: 2}, fieldSep{regex: regexp.MustCompile(`\b`)}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getKeyColumn(tt.line, tt.key, tt.sep); got != tt.want {
+				t.Errorf("getKeyColumn() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort_FieldSeparators(t *testing.T) {
+	input := "b::10::x\na::9::y\nc::100::z\n"
+	byNumber := []SortKey{{StartField: 2, EndField: 2, NumericSort: true}}
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"multi_char", Options{Keys: byNumber, Separator: "::"}, "a::9::y\nb::10::x\nc::100::z\n"},
+		{"regex", Options{Keys: byNumber, FieldRegex: regexp.MustCompile(`:+`)}, "a::9::y\nb::10::x\nc::100::z\n"},
+		{"single_colon_empty_key", Options{Keys: byNumber, Separator: ":", Stable: true}, input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := Sort(t.Context(), []io.Reader{strings.NewReader(input)}, &got, tt.opts); err != nil {
+				t.Fatalf("Sort failed: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldRegex(t *testing.T) {
+	for _, expr := range []string{":+", ` *[,;] *`, `^x`, `\s{2,}`, `a|bc`} {
+		if _, err := ParseFieldRegex(expr); err != nil {
+			t.Errorf("ParseFieldRegex(%q) failed: %v", expr, err)
+		}
+	}
+	for _, expr := range []string{`\b`, "x*", "(a|)", "x{0,3}", "^", "["} {
+		if _, err := ParseFieldRegex(expr); err == nil {
+			t.Errorf("ParseFieldRegex(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestSortReaders_ExternalMergePasses(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	var input strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&input, "%d %d\n", rng.IntN(30), i)
+	}
+	byFirst := []SortKey{{StartField: 1, EndField: 1, NumericSort: true}}
+
+	for name, opts := range map[string]Options{
+		"stable_key":  {Keys: byFirst, Stable: true},
+		"unique_key":  {Keys: byFirst, Unique: true},
+		"count_key":   {Keys: byFirst, Count: true},
+		"whole_lines": {Reverse: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts.TempDir = t.TempDir()
+			want := sortText(t, opts, 0, input.String())
+			// one run per line: far more runs than mergeFanIn, merged in passes
+			if got := sortText(t, opts, 1, input.String()); got != want {
+				t.Errorf("external sort with one run per line differs from in-memory sort")
+			}
+
+			entries, err := os.ReadDir(opts.TempDir)
+			if err != nil {
+				t.Fatalf("failed to read temp dir: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("temporary runs were not removed: %d files left", len(entries))
+			}
+		})
+	}
+}
+
+func TestComparator_LineCost(t *testing.T) {
+	line := "42 some text"
+	plain := NewComparator(Options{}).lineCost(line)
+	twoKeys := NewComparator(Options{Keys: []SortKey{{StartField: 1, EndField: 1, NumericSort: true}, {StartField: 2}}}).lineCost(line)
+	folded := NewComparator(Options{Keys: []SortKey{{StartField: 2, FoldCase: true}}}).lineCost(line)
+	collated := NewComparator(Options{Locale: "ru-RU"}).lineCost(line)
+
+	// the line, its two records and one keyValue per key
+	if floor := int64(len(line)) + 2*int64(unsafe.Sizeof(record{})); plain < floor {
+		t.Errorf("plain cost %d, want at least %d", plain, floor)
+	}
+	if want := plain + 2*int64(unsafe.Sizeof(keyValue{})); twoKeys != want {
+		t.Errorf("cost with two keys = %d, want %d", twoKeys, want)
+	}
+	if want := plain + int64(unsafe.Sizeof(keyValue{})) + int64(len(line)); folded != want {
+		t.Errorf("cost with -f = %d, want %d", folded, want)
+	}
+	if collated <= plain+int64(len(line)) {
+		t.Errorf("cost under --locale = %d, want more than %d", collated, plain+int64(len(line)))
+	}
+}
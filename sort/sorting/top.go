@@ -0,0 +1,96 @@
+package sorting
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"io"
+	"slices"
+)
+
+// topRecord is a line kept by --head or --tail with its input position,
+// which orders lines with equal keys as a stable sort would.
+type topRecord struct {
+	record
+	index int
+}
+
+// topHeap holds the best n lines seen so far. Its root is the kept line
+// that goes first when a better one arrives: the last one for --head and
+// the first one for --tail.
+type topHeap struct {
+	recs []topRecord
+	comp *Comparator
+	tail bool
+}
+
+func (h *topHeap) order(a, b topRecord) int {
+	if diff := h.comp.compareRecords(a.record, b.record); diff != 0 {
+		return diff
+	}
+	return cmp.Compare(a.index, b.index)
+}
+
+func (h *topHeap) Len() int { return len(h.recs) }
+
+// Less puts the worst kept line at the root.
+func (h *topHeap) Less(i, j int) bool { return h.better(h.recs[j], h.recs[i]) }
+
+func (h *topHeap) Swap(i, j int) { h.recs[i], h.recs[j] = h.recs[j], h.recs[i] }
+
+func (h *topHeap) Push(x any) { h.recs = append(h.recs, x.(topRecord)) }
+
+func (h *topHeap) Pop() any {
+	last := h.recs[len(h.recs)-1]
+	h.recs = h.recs[:len(h.recs)-1]
+	return last
+}
+
+// add offers rec to a heap that keeps at most n lines.
+func (h *topHeap) add(rec topRecord, n int) {
+	if len(h.recs) < n {
+		heap.Push(h, rec)
+		return
+	}
+	if !h.better(rec, h.recs[0]) {
+		return
+	}
+	h.recs[0] = rec
+	heap.Fix(h, 0)
+}
+
+// better reports whether a belongs in the result rather than b.
+func (h *topHeap) better(a, b topRecord) bool {
+	if h.tail {
+		return h.order(a, b) > 0
+	}
+	return h.order(a, b) < 0
+}
+
+// sortTop writes the first opts.Head or the last opts.Tail lines of the
+// sorted inputs to out. Only those lines are kept in memory, so the output
+// equals that of a full sort cut by head or tail at O(n log N) cost.
+func sortTop(ctx context.Context, inputs []io.Reader, opts Options, out io.Writer) error {
+	comp := NewComparator(opts)
+	h := &topHeap{comp: comp, tail: opts.Tail > 0}
+	n := max(opts.Head, opts.Tail)
+
+	index := 0
+	for _, input := range inputs {
+		err := scanLines(ctx, input, opts, func(line string) error {
+			h.add(topRecord{record: comp.decorate(line), index: index}, n)
+			index++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	slices.SortFunc(h.recs, h.order)
+	lines := make([]string, len(h.recs))
+	for i, rec := range h.recs {
+		lines[i] = rec.line
+	}
+	return writeLines(ctx, out, lines, comp, opts)
+}
@@ -0,0 +1,225 @@
+package sorting
+
+import (
+	"cmp"
+	"regexp"
+	"strings"
+)
+
+// filevercmp compares two version strings the way GNU sort -V does: runs of
+// digits compare numerically, letters sort before other characters, '~'
+// sorts before everything (even the end of the string), and file suffixes
+// such as ".tar.gz" only decide when the rest is equal.
+func filevercmp(a, b string) int {
+	if a == "" || b == "" {
+		return cmp.Compare(len(a), len(b))
+	}
+
+	// "." sorts first, then "..", then other names with a leading dot.
+	if a[0] == '.' {
+		if b[0] != '.' {
+			return -1
+		}
+		for _, special := range []string{".", ".."} {
+			if a == special || b == special {
+				return boolOrder(b == special) - boolOrder(a == special)
+			}
+		}
+	} else if b[0] == '.' {
+		return 1
+	}
+
+	aPrefix, bPrefix := filePrefixLen(a), filePrefixLen(b)
+	if diff := verrevcmp(a[:aPrefix], b[:bPrefix]); diff != 0 {
+		return diff
+	}
+	if aPrefix == len(a) && bPrefix == len(b) {
+		return 0
+	}
+	return verrevcmp(a, b)
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// filePrefixLen returns the length of s without its file suffix, which
+// matches (\.[A-Za-z~][A-Za-z0-9~]*)*$. A name such as ".bashrc" is all
+// suffix.
+func filePrefixLen(s string) int {
+	prefix := 0
+	for i := 0; ; i++ {
+		for i+1 < len(s) && s[i] == '.' && (isAlpha(s[i+1]) || s[i+1] == '~') {
+			for i += 2; i < len(s) && (isAlpha(s[i]) || isDecimalDigit(s[i]) || s[i] == '~'); i++ {
+			}
+		}
+		if i >= len(s) {
+			return prefix
+		}
+		prefix = i + 1
+	}
+}
+
+// versionOrder ranks the character at pos of s for verrevcmp.
+func versionOrder(s string, pos int) int {
+	if pos == len(s) {
+		return -1
+	}
+	c := s[pos]
+	switch {
+	case isDecimalDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -2
+	}
+	return int(c) + 256
+}
+
+// verrevcmp is the Debian version comparison: it alternately compares
+// non-digit runs by versionOrder and digit runs by numeric value.
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDecimalDigit(a[i])) || (j < len(b) && !isDecimalDigit(b[j])) {
+			ca, cb := versionOrder(a, i), versionOrder(b, j)
+			if ca != cb {
+				return ca - cb
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDecimalDigit(a[i]) && isDecimalDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDecimalDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDecimalDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+func isAlpha(c byte) bool {
+	return (c|0x20) >= 'a' && (c|0x20) <= 'z'
+}
+
+var semverPattern = regexp.MustCompile(
+	`(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?`)
+
+// semVersion is the first MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] found in a
+// key, with the text around it.
+type semVersion struct {
+	prefix     string
+	core       [3]string
+	prerelease []string
+	suffix     string
+}
+
+func parseSemver(s string) *semVersion {
+	m := semverPattern.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil
+	}
+
+	v := &semVersion{prefix: s[:m[0]], suffix: s[m[1]:]}
+	for i := range v.core {
+		v.core[i] = s[m[2+2*i]:m[3+2*i]]
+	}
+	if m[8] >= 0 {
+		v.prerelease = strings.Split(s[m[8]:m[9]], ".")
+	}
+	return v
+}
+
+// compareSemver orders keys by SemVer 2.0 precedence: the text before the
+// version, then the version itself, where a pre-release sorts before the
+// release and build metadata is ignored, then the text after it. Keys
+// without a semantic version fall back to filevercmp.
+func compareSemver(a, b string, va, vb *semVersion) int {
+	if va == nil || vb == nil {
+		return filevercmp(a, b)
+	}
+
+	if diff := filevercmp(va.prefix, vb.prefix); diff != 0 {
+		return diff
+	}
+	for i := range va.core {
+		if diff := compareNumericIdent(va.core[i], vb.core[i]); diff != 0 {
+			return diff
+		}
+	}
+	if diff := comparePrerelease(va.prerelease, vb.prerelease); diff != 0 {
+		return diff
+	}
+	return verrevcmp(va.suffix, vb.suffix) // keeps "1.0.0~rc1" before "1.0.0"
+}
+
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1 // a release sorts after its pre-releases
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		aNum, bNum := isNumericIdent(a[i]), isNumericIdent(b[i])
+		var diff int
+		switch {
+		case aNum && bNum:
+			diff = compareNumericIdent(a[i], b[i])
+		case aNum:
+			diff = -1 // numeric identifiers sort before alphanumeric ones
+		case bNum:
+			diff = 1
+		default:
+			diff = strings.Compare(a[i], b[i])
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isNumericIdent(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDecimalDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func compareNumericIdent(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if diff := cmp.Compare(len(a), len(b)); diff != 0 {
+		return diff
+	}
+	return strings.Compare(a, b)
+}